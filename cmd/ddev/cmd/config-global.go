@@ -143,6 +143,12 @@ func handleGlobalConfig(cmd *cobra.Command, args []string) {
 		dirty = true
 	}
 
+	if cmd.Flag("docker-compose-command").Changed {
+		val, _ := cmd.Flags().GetString("docker-compose-command")
+		globalconfig.DdevGlobalConfig.DockerComposeCommand = val
+		dirty = true
+	}
+
 	if cmd.Flag("no-bind-mounts").Changed {
 		val, _ := cmd.Flags().GetBool("no-bind-mounts")
 		globalconfig.DdevGlobalConfig.NoBindMounts = val
@@ -178,6 +184,7 @@ func handleGlobalConfig(cmd *cobra.Command, args []string) {
 	output.UserOut.Printf("fail-on-hook-fail=%v", globalconfig.DdevGlobalConfig.FailOnHookFailGlobal)
 	output.UserOut.Printf("required-docker-compose-version=%v", globalconfig.DdevGlobalConfig.RequiredDockerComposeVersion)
 	output.UserOut.Printf("use-docker-compose-from-path=%v", globalconfig.DdevGlobalConfig.UseDockerComposeFromPath)
+	output.UserOut.Printf("docker-compose-command=%v", globalconfig.DdevGlobalConfig.DockerComposeCommand)
 	output.UserOut.Printf("no-bind-mounts=%v", globalconfig.DdevGlobalConfig.NoBindMounts)
 }
 
@@ -199,6 +206,7 @@ func init() {
 	configGlobalCommand.Flags().String("table-style", "", "Table style for list and describe, see ~/.ddev/global_config.yaml for values")
 	configGlobalCommand.Flags().String("required-docker-compose-version", "", "Override default docker-compose version")
 	configGlobalCommand.Flags().Bool("use-docker-compose-from-path", true, "If true, use docker-compose from path instead of private ~/.ddev/bin/docker-compose")
+	configGlobalCommand.Flags().String("docker-compose-command", "", `Command to use instead of the private docker-compose, for example --docker-compose-command="docker compose"`)
 	configGlobalCommand.Flags().Bool("no-bind-mounts", true, "If true, don't use bind-mounts - useful for environments like remote docker where bind-mounts are impossible")
 
 	ConfigCommand.AddCommand(configGlobalCommand)
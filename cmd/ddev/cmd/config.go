@@ -236,6 +236,13 @@ func init() {
 	ConfigCommand.Flags().StringVar(&httpPortArg, "http-port", "", "The router HTTP port for this project")
 	ConfigCommand.Flags().StringVar(&httpsPortArg, "https-port", "", "The router HTTPS port for this project")
 	ConfigCommand.Flags().BoolVar(&xdebugEnabledArg, "xdebug-enabled", false, "Whether or not XDebug is enabled in the web container")
+
+	ConfigCommand.Flags().Int("xdebug-port", 0, "Specify the port xdebug connects to on the host (default 9003)")
+
+	ConfigCommand.Flags().String("xdebug-ide-key", "", `Specify the xdebug IDE key, like "PHPSTORM"`)
+	ConfigCommand.Flags().Int("webserver-workers", 0, "Specify the number of nginx worker processes or apache server processes")
+	ConfigCommand.Flags().String("fastcgi-buffers", "", `Specify the nginx fastcgi_buffers, like "32 16k"`)
+	ConfigCommand.Flags().String("fastcgi-buffer-size", "", `Specify the nginx fastcgi_buffer_size, like "64k"`)
 	ConfigCommand.Flags().BoolVar(&noProjectMountArg, "no-project-mount", false, "Whether or not to skip mounting project code into the web container")
 	ConfigCommand.Flags().StringVar(&additionalHostnamesArg, "additional-hostnames", "", "A comma-delimited list of hostnames for the project")
 	ConfigCommand.Flags().StringVar(&additionalFQDNsArg, "additional-fqdns", "", "A comma-delimited list of FQDNs for the project")
@@ -252,6 +259,7 @@ func init() {
 	ConfigCommand.Flags().StringVar(&dbaImageArg, "dba-image", "", "Sets the dba container image")
 	ConfigCommand.Flags().BoolVar(&dbaImageDefaultArg, "dba-image-default", false, "Sets the default dba container image for this ddev version")
 	ConfigCommand.Flags().BoolVar(&imageDefaultsArg, "image-defaults", false, "Sets the default web, db, and dba container images")
+	ConfigCommand.Flags().String("image-registry", "", `Sets a registry or mirror to pull the web, db, and dba container images from, like "myregistry.local/ddev"`)
 	ConfigCommand.Flags().StringVar(&webWorkingDirArg, "web-working-dir", "", "Overrides the default working directory for the web service")
 	ConfigCommand.Flags().StringVar(&dbWorkingDirArg, "db-working-dir", "", "Overrides the default working directory for the db service")
 	ConfigCommand.Flags().StringVar(&dbaWorkingDirArg, "dba-working-dir", "", "Overrides the default working directory for the dba service")
@@ -311,8 +319,54 @@ func init() {
 
 	ConfigCommand.Flags().Bool("disable-settings-management", false, "Prevent ddev from creating or updating CMS settings files")
 
+	ConfigCommand.Flags().Bool("disable-hosts-management", false, "Prevent ddev from adding the project's hostnames to the hosts file or removing them")
+
 	ConfigCommand.Flags().String("composer-version", "", `Specify override for composer version in web container. This may be "", "1", "2", or a specific version.`)
 
+	ConfigCommand.Flags().String("nodejs-version", "", `Specify the nodejs major version to install in the web container, like "14". If "", the bundled nodejs is used.`)
+
+	ConfigCommand.Flags().String("restart-policy", "", `Specify the restart policy of the project's containers: "no", "unless-stopped" or "always"`)
+
+	ConfigCommand.Flags().Bool("expose-db-port", true, "Publish the database port to the host; set to false to only reach the database from the project's containers")
+
+	ConfigCommand.Flags().String("mount-type", "", `Specify how the project is mounted into the web container: "bind", "cached", "delegated" or "sync"`)
+
+	ConfigCommand.Flags().String("existing-containers", "", `Specify whether start reuses the project's existing containers or recreates them: "reuse" or "recreate"`)
+
+	ConfigCommand.Flags().String("stop-grace-period", "", `Specify how long containers get to shut down cleanly on stop before they are killed, like "30s"`)
+
+	ConfigCommand.Flags().String("import-timeout", "", `Specify how long a database import may take before it's killed, like "30m"`)
+
+	ConfigCommand.Flags().String("log-driver", "", `Specify the docker logging driver of the project's containers, like "json-file" or "local"`)
+
+	ConfigCommand.Flags().String("log-max-size", "", `Specify the size at which container logs are rotated, like "10m"`)
+
+	ConfigCommand.Flags().Int("log-max-file", 0, "Specify how many rotated log files are kept for each container")
+
+	ConfigCommand.Flags().String("web-command", "", `Specify a command to run in the web container instead of the web server and php-fpm, like "php artisan queue:work"`)
+
+	ConfigCommand.Flags().String("extra-hosts", "", `A comma-delimited list of hostname:ip entries to add to the web container's /etc/hosts, like "myservice:10.0.0.5"`)
+
+	ConfigCommand.Flags().String("dns", "", `A comma-delimited list of DNS servers for the web and db containers, like "10.0.0.2,10.0.0.3"`)
+
+	ConfigCommand.Flags().String("dns-search", "", `A comma-delimited list of DNS search domains for the web and db containers, like "corp.example.com"`)
+
+	ConfigCommand.Flags().String("generated-compose-dir", "", "Specify the directory, relative to the project root, that the generated docker-compose files are written to instead of .ddev")
+
+	ConfigCommand.Flags().String("import-files-owner", "", `Specify the user or user:group imported files are given in the web container, like "www-data:www-data"`)
+
+	ConfigCommand.Flags().Bool("directory-listing", false, "Let the web server list the files in directories that have no index file")
+
+	ConfigCommand.Flags().Bool("validate-compose", false, "Check the project's docker-compose files with docker-compose config on start")
+
+	ConfigCommand.Flags().String("web-env-file", "", "Specify a file of VAR=value lines, relative to the project root, to put into the web container's environment instead of .env")
+
+	ConfigCommand.Flags().Bool("docroot-read-only", false, "Mount the docroot read-only in the web container, except for the upload dir or docroot_writable_dirs")
+
+	ConfigCommand.Flags().String("docroot-writable-dirs", "", `A comma-delimited list of directories relative to the docroot that stay writable with --docroot-read-only, like "sites/default/files,tmp"`)
+
+	ConfigCommand.Flags().String("multisites", "", `A comma-delimited list of Drupal multisites, each like "name" or "name:hostname" or "name:hostname:database"`)
+
 	ConfigCommand.Flags().Bool("auto", true, `Automatically run config without prompting.`)
 	ConfigCommand.Flags().Bool("bind-all-interfaces", false, `Bind host ports on all interfaces, not just on localhost network interface`)
 
@@ -509,6 +563,26 @@ func handleMainConfigArgs(cmd *cobra.Command, args []string, app *ddevapp.DdevAp
 		app.XdebugEnabled = xdebugEnabledArg
 	}
 
+	if cmd.Flag("xdebug-port").Changed {
+		app.XdebugPort, _ = cmd.Flags().GetInt("xdebug-port")
+	}
+
+	if cmd.Flag("xdebug-ide-key").Changed {
+		app.XdebugIDEKey, _ = cmd.Flags().GetString("xdebug-ide-key")
+	}
+
+	if cmd.Flag("webserver-workers").Changed {
+		app.WebserverWorkers, _ = cmd.Flags().GetInt("webserver-workers")
+	}
+
+	if cmd.Flag("fastcgi-buffers").Changed {
+		app.FastCGIBuffers, _ = cmd.Flags().GetString("fastcgi-buffers")
+	}
+
+	if cmd.Flag("fastcgi-buffer-size").Changed {
+		app.FastCGIBufferSize, _ = cmd.Flags().GetString("fastcgi-buffer-size")
+	}
+
 	// This bool flag is false by default, so only use the value if the flag was explicitly set.
 	if cmd.Flag("no-project-mount").Changed {
 		app.NoProjectMount = noProjectMountArg
@@ -595,10 +669,137 @@ func handleMainConfigArgs(cmd *cobra.Command, args []string, app *ddevapp.DdevAp
 		}
 	}
 
+	if cmd.Flag("nodejs-version").Changed {
+		app.NodeJSVersion, err = cmd.Flags().GetString("nodejs-version")
+		if err != nil {
+			util.Failed("Incorrect nodejs-version: %v", err)
+		}
+	}
+
+	if cmd.Flag("restart-policy").Changed {
+		app.RestartPolicy, _ = cmd.Flags().GetString("restart-policy")
+	}
+
+	if cmd.Flag("expose-db-port").Changed {
+		exposeDBPort, _ := cmd.Flags().GetBool("expose-db-port")
+		app.ExposeDBPort = &exposeDBPort
+	}
+
+	if cmd.Flag("mount-type").Changed {
+		app.MountType, _ = cmd.Flags().GetString("mount-type")
+	}
+
+	if cmd.Flag("existing-containers").Changed {
+		app.ExistingContainers, _ = cmd.Flags().GetString("existing-containers")
+	}
+
+	if cmd.Flag("stop-grace-period").Changed {
+		app.StopGracePeriod, _ = cmd.Flags().GetString("stop-grace-period")
+	}
+
+	if cmd.Flag("import-timeout").Changed {
+		app.ImportTimeout, _ = cmd.Flags().GetString("import-timeout")
+	}
+
+	if cmd.Flag("log-driver").Changed {
+		app.LogDriver, _ = cmd.Flags().GetString("log-driver")
+	}
+
+	if cmd.Flag("log-max-size").Changed {
+		app.LogMaxSize, _ = cmd.Flags().GetString("log-max-size")
+	}
+
+	if cmd.Flag("log-max-file").Changed {
+		app.LogMaxFile, _ = cmd.Flags().GetInt("log-max-file")
+	}
+
+	if cmd.Flag("web-command").Changed {
+		app.WebCommand, _ = cmd.Flags().GetString("web-command")
+	}
+
+	if cmd.Flag("extra-hosts").Changed {
+		val, _ := cmd.Flags().GetString("extra-hosts")
+		app.ExtraHosts = nil
+		if val != "" {
+			app.ExtraHosts = strings.Split(val, ",")
+		}
+	}
+
+	if cmd.Flag("dns").Changed {
+		val, _ := cmd.Flags().GetString("dns")
+		app.DNS = nil
+		if val != "" {
+			app.DNS = strings.Split(val, ",")
+		}
+	}
+
+	if cmd.Flag("dns-search").Changed {
+		val, _ := cmd.Flags().GetString("dns-search")
+		app.DNSSearch = nil
+		if val != "" {
+			app.DNSSearch = strings.Split(val, ",")
+		}
+	}
+
+	if cmd.Flag("generated-compose-dir").Changed {
+		app.GeneratedComposeDir, _ = cmd.Flags().GetString("generated-compose-dir")
+	}
+
+	if cmd.Flag("import-files-owner").Changed {
+		app.ImportFilesOwner, _ = cmd.Flags().GetString("import-files-owner")
+	}
+
+	if cmd.Flag("directory-listing").Changed {
+		app.DirectoryListing, _ = cmd.Flags().GetBool("directory-listing")
+	}
+
+	if cmd.Flag("validate-compose").Changed {
+		app.ValidateComposeOnStart, _ = cmd.Flags().GetBool("validate-compose")
+	}
+
+	if cmd.Flag("web-env-file").Changed {
+		app.WebEnvFile, _ = cmd.Flags().GetString("web-env-file")
+	}
+
+	if cmd.Flag("docroot-read-only").Changed {
+		app.DocrootReadOnly, _ = cmd.Flags().GetBool("docroot-read-only")
+	}
+
+	if cmd.Flag("docroot-writable-dirs").Changed {
+		val, _ := cmd.Flags().GetString("docroot-writable-dirs")
+		app.DocrootWritableDirs = nil
+		if val != "" {
+			app.DocrootWritableDirs = strings.Split(val, ",")
+		}
+	}
+
+	if cmd.Flag("multisites").Changed {
+		multisitesArg, _ := cmd.Flags().GetString("multisites")
+		app.Multisites = nil
+		for _, entry := range strings.Split(multisitesArg, ",") {
+			if entry == "" {
+				continue
+			}
+			parts := strings.SplitN(entry, ":", 3)
+			site := ddevapp.Multisite{Name: parts[0]}
+			if len(parts) > 1 {
+				site.Hostname = parts[1]
+			}
+			if len(parts) > 2 {
+				site.Database = parts[2]
+			}
+			app.Multisites = append(app.Multisites, site)
+		}
+	}
+
 	if cmd.Flag("disable-settings-management").Changed {
 		app.DisableSettingsManagement, _ = cmd.Flags().GetBool("disable-settings-management")
 	}
 
+	if cmd.Flag("disable-hosts-management").Changed {
+		app.DisableHostsManagement, _ = cmd.Flags().GetBool("disable-hosts-management")
+	}
+
 	if cmd.Flag("bind-all-interfaces").Changed {
 		app.BindAllInterfaces, _ = cmd.Flags().GetBool("bind-all-interfaces")
 	}
@@ -641,6 +842,10 @@ func handleMainConfigArgs(cmd *cobra.Command, args []string, app *ddevapp.DdevAp
 		app.DBAImage = ""
 	}
 
+	if cmd.Flag("image-registry").Changed {
+		app.ImageRegistry, _ = cmd.Flags().GetString("image-registry")
+	}
+
 	if app.WorkingDir == nil {
 		app.WorkingDir = map[string]string{}
 	}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/drud/ddev/pkg/ddevapp"
+	"github.com/drud/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// DebugClockSkewCmd implements the ddev debug clock-skew command
+var DebugClockSkewCmd = &cobra.Command{
+	Use:     "clock-skew [project]",
+	Short:   "Checks that the clock of a project's web container agrees with the host's",
+	Example: "ddev debug clock-skew, ddev debug clock-skew <projectname>",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectName := ""
+
+		if len(args) > 1 {
+			util.Failed("This command only takes one optional argument: project-name")
+		}
+
+		if len(args) == 1 {
+			projectName = args[0]
+		}
+
+		app, err := ddevapp.GetActiveApp(projectName)
+		if err != nil {
+			util.Failed("Failed to get active project: %v", err)
+		}
+		if app.SiteStatus() != ddevapp.SiteRunning {
+			util.Failed("Project %s is not running, start it with 'ddev start'", app.Name)
+		}
+		skew, err := app.GetClockSkew()
+		if err != nil {
+			util.Failed("Failed to check the clock of project %s: %v", app.Name, err)
+		}
+		err = app.CheckClockSkew()
+		if err != nil {
+			util.Failed("%v", err)
+		}
+		util.Success("The web container's clock of project %s is %s from the host's", app.Name, skew.Round(time.Millisecond))
+	},
+}
+
+func init() {
+	DebugCmd.AddCommand(DebugClockSkewCmd)
+}
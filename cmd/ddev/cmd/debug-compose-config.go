@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"github.com/drud/ddev/pkg/fileutil"
 	"strings"
 
 	"github.com/drud/ddev/pkg/ddevapp"
@@ -30,14 +29,9 @@ var DebugComposeConfigCmd = &cobra.Command{
 			util.Failed("Failed to get compose-config: %v", err)
 		}
 
-		app.DockerEnv()
-		if err = app.WriteDockerComposeYAML(); err != nil {
-			util.Failed("Failed to get compose-config: %v", err)
-		}
-
-		out, err := fileutil.ReadFileIntoString(app.DockerComposeFullRenderedYAMLPath())
+		out, err := app.EffectiveComposeYAML()
 		if err != nil {
-			util.Failed("unable to read rendered file %s: %v", app.DockerComposeFullRenderedYAMLPath(), err)
+			util.Failed("Failed to get compose-config: %v", err)
 		}
 		output.UserOut.Print(strings.TrimSpace(out))
 	},
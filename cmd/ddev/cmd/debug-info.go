@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/drud/ddev/pkg/ddevapp"
+	"github.com/drud/ddev/pkg/output"
+	"github.com/drud/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// DebugInfoCmd implements the ddev debug info command
+var DebugInfoCmd = &cobra.Command{
+	Use:     "info [project]",
+	Short:   "Prints what ddev detected about a project, with secrets masked, for bug reports",
+	Example: "ddev debug info, ddev debug info <projectname>",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectName := ""
+
+		if len(args) > 1 {
+			util.Failed("This command only takes one optional argument: project-name")
+		}
+
+		if len(args) == 1 {
+			projectName = args[0]
+		}
+
+		app, err := ddevapp.GetActiveApp(projectName)
+		if err != nil {
+			util.Failed("Failed to get active project: %v", err)
+		}
+		info, err := app.DebugInfo()
+		if err != nil {
+			util.Failed("Failed to get debug info for project %s: %v", app.Name, err)
+		}
+		output.UserOut.Print(info)
+	},
+}
+
+func init() {
+	DebugCmd.AddCommand(DebugInfoCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/drud/ddev/pkg/ddevapp"
+	"github.com/drud/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// DebugRepairComposeCmd implements the ddev debug repair-compose command
+var DebugRepairComposeCmd = &cobra.Command{
+	Use:     "repair-compose [project]",
+	Short:   "Regenerates a project's generated docker-compose files from its config, keeping broken ones with a .broken suffix",
+	Example: "ddev debug repair-compose, ddev debug repair-compose <projectname>",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectName := ""
+
+		if len(args) > 1 {
+			util.Failed("This command only takes one optional argument: project-name")
+		}
+
+		if len(args) == 1 {
+			projectName = args[0]
+		}
+
+		app, err := ddevapp.GetActiveApp(projectName)
+		if err != nil {
+			util.Failed("Failed to get active project: %v", err)
+		}
+		err = app.RepairCompose()
+		if err != nil {
+			util.Failed("Failed to repair the docker-compose files of project %s: %v", app.Name, err)
+		}
+		util.Success("Regenerated the docker-compose files of project %s", app.Name)
+	},
+}
+
+func init() {
+	DebugCmd.AddCommand(DebugRepairComposeCmd)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/drud/ddev/pkg/ddevapp"
+	"github.com/drud/ddev/pkg/output"
+	"github.com/drud/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// DebugStartTimingsCmd implements the ddev debug start-timings command
+var DebugStartTimingsCmd = &cobra.Command{
+	Use:     "start-timings [project]",
+	Short:   "Shows how long the steps of the last ddev start of a project took",
+	Example: "ddev debug start-timings, ddev debug start-timings <projectname>",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectName := ""
+
+		if len(args) > 1 {
+			util.Failed("This command only takes one optional argument: project-name")
+		}
+
+		if len(args) == 1 {
+			projectName = args[0]
+		}
+
+		app, err := ddevapp.GetActiveApp(projectName)
+		if err != nil {
+			util.Failed("Failed to get active project: %v", err)
+		}
+		timings := app.LastStartTimings()
+		if len(timings) == 0 {
+			util.Failed("No start timings have been recorded for project %s, please ddev start it first", app.Name)
+		}
+
+		// Show the slowest steps first, with the total last
+		steps := make([]string, 0, len(timings))
+		for step := range timings {
+			if step != "total" {
+				steps = append(steps, step)
+			}
+		}
+		sort.Slice(steps, func(i, j int) bool {
+			return timings[steps[i]] > timings[steps[j]]
+		})
+		var lines []string
+		for _, step := range append(steps, "total") {
+			lines = append(lines, fmt.Sprintf("%s: %s", step, timings[step].Round(10*time.Millisecond)))
+		}
+		output.UserOut.Println(strings.Join(lines, "\n"))
+	},
+}
+
+func init() {
+	DebugCmd.AddCommand(DebugStartTimingsCmd)
+}
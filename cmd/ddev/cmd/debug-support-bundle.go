@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/drud/ddev/pkg/ddevapp"
+	"github.com/drud/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// DebugSupportBundleCmd implements the ddev debug support-bundle command
+var DebugSupportBundleCmd = &cobra.Command{
+	Use:     "support-bundle [project]",
+	Short:   "Writes a zip file with a project's compose file, config, description and recent logs, with secrets masked, for bug reports",
+	Example: "ddev debug support-bundle, ddev debug support-bundle <projectname> --output=/tmp/bundle.zip",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectName := ""
+
+		if len(args) > 1 {
+			util.Failed("This command only takes one optional argument: project-name")
+		}
+
+		if len(args) == 1 {
+			projectName = args[0]
+		}
+
+		app, err := ddevapp.GetActiveApp(projectName)
+		if err != nil {
+			util.Failed("Failed to get active project: %v", err)
+		}
+		outputPath, _ := cmd.Flags().GetString("output")
+		if outputPath == "" {
+			outputPath = app.Name + "-support-bundle.zip"
+		}
+		err = app.SupportBundle(outputPath)
+		if err != nil {
+			util.Failed("Failed to create support bundle for project %s: %v", app.Name, err)
+		}
+		util.Success("Wrote support bundle for project %s to %s", app.Name, outputPath)
+	},
+}
+
+func init() {
+	DebugSupportBundleCmd.Flags().StringP("output", "o", "", "Path of the zip file to write, <project>-support-bundle.zip by default")
+	DebugCmd.AddCommand(DebugSupportBundleCmd)
+}
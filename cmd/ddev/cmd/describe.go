@@ -52,6 +52,18 @@ running 'ddev describe <projectname>'.`,
 		renderedDesc, err := renderAppDescribe(app, desc)
 		util.CheckErr(err) // We shouldn't ever end up with an unrenderable desc.
 		output.UserOut.WithField("raw", desc).Print(renderedDesc)
+
+		if desc["status"] == ddevapp.SiteRunning {
+			images, err := app.ImageStatus()
+			if err != nil {
+				util.Warning("Unable to check the images of project %s: %v", app.Name, err)
+			}
+			for _, service := range []string{"web", "db"} {
+				if info, ok := images[service]; ok && info.ImageID != "" && !info.UpToDate {
+					util.Warning("The %s container is not using the latest %s, please 'ddev restart' to update it", service, info.Image)
+				}
+			}
+		}
 	},
 }
 
@@ -155,6 +167,12 @@ func renderAppDescribe(app *ddevapp.DdevApp, desc map[string]interface{}) (strin
 			t.AppendRow(table.Row{k, ddevapp.FormatSiteStatus(v["status"]), strings.Join(urlPortParts, "\n"), strings.Join(extraInfo, "\n")})
 		}
 
+		if multisites, ok := desc["multisites"].([]map[string]string); ok {
+			for _, site := range multisites {
+				t.AppendRow(table.Row{"Multisite", "", site["url"], fmt.Sprintf("sites/%s\ndatabase: '%s'", site["name"], site["database"])})
+			}
+		}
+
 		if !ddevapp.IsRouterDisabled(app) {
 			mailhogURL := ""
 			if _, ok := desc["mailhog_url"]; ok {
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/drud/ddev/pkg/ddevapp"
 	"github.com/drud/ddev/pkg/dockerutil"
 	"github.com/drud/ddev/pkg/util"
@@ -10,6 +13,7 @@ import (
 var outFileName string
 var gzipOption bool
 var exportTargetDB string
+var exportCompatible string
 
 // ExportDBCmd is the `ddev export-db` command.
 var ExportDBCmd = &cobra.Command{
@@ -22,7 +26,8 @@ ddev export-db --gzip=false --file /tmp/db.sql
 ddev export-db > /tmp/db.sql.gz
 ddev export-db --gzip=false > /tmp/db.sql
 ddev export-db myproject --gzip=false --file=/tmp/myproject.sql
-ddev export-db someproject --gzip=false --file=/tmp/someproject.sql `,
+ddev export-db someproject --gzip=false --file=/tmp/someproject.sql
+ddev export-db --compatible=mysql:5.7 --file=/tmp/db.sql.gz`,
 	Args: cobra.RangeArgs(0, 1),
 	PreRun: func(cmd *cobra.Command, args []string) {
 		dockerutil.EnsureDdevNetwork()
@@ -39,7 +44,7 @@ ddev export-db someproject --gzip=false --file=/tmp/someproject.sql `,
 			util.Failed("ddev can't export-db until the project is started, please use ddev start.")
 		}
 
-		err = app.ExportDB(outFileName, gzipOption, exportTargetDB)
+		err = app.ExportDBCompatible(outFileName, gzipOption, exportTargetDB, exportCompatible)
 		if err != nil {
 			util.Failed("Failed to export database for %s: %v", app.GetName(), err)
 		}
@@ -50,5 +55,6 @@ func init() {
 	ExportDBCmd.Flags().StringVarP(&outFileName, "file", "f", "", "Provide the path to output the dump")
 	ExportDBCmd.Flags().BoolVarP(&gzipOption, "gzip", "z", true, "If provided asset is an archive, provide the path to extract within the archive.")
 	ExportDBCmd.Flags().StringVarP(&exportTargetDB, "target-db", "d", "db", "If provided, target-db is alternate database to export")
+	ExportDBCmd.Flags().StringVar(&exportCompatible, "compatible", "", fmt.Sprintf("Make a dump that can be imported into another database server: %s", strings.Join(ddevapp.ValidExportCompat, ", ")))
 	RootCmd.AddCommand(ExportDBCmd)
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"errors"
+
 	"github.com/drud/ddev/pkg/ddevapp"
 	"github.com/drud/ddev/pkg/dockerutil"
 	"github.com/drud/ddev/pkg/util"
@@ -12,6 +14,8 @@ var dbExtPath string
 var targetDB string
 var noDrop bool
 var progressOption bool
+var forceImport bool
+var fromVolume string
 
 // ImportDBCmd represents the `ddev import-db` command.
 var ImportDBCmd = &cobra.Command{
@@ -23,11 +27,16 @@ The database dump file can be provided as a SQL dump in a .sql, .sql.gz, .mysql,
 format. For the zip and tar formats, the path to a .sql file within the archive
 can be provided if it is not located at the top level of the archive. An optional target database
 can also be provided; the default is the default database named "db".
+If the database was last imported from the same dump, it isn't imported
+again unless --force is given.
+With --from-volume the whole database data directory is replaced by the
+one in a docker volume, which must come from the same db server version.
 Also note the related "ddev mysql" command`,
 	Example: `ddev import-db
 ddev import-db --src=.tarballs/junk.sql
 ddev import-db --src=.tarballs/junk.sql.gz
 ddev import-db --target-db=newdb --src=.tarballs/junk.sql.gz
+ddev import-db --from-volume=ci-seeded-db
 ddev import-db <db.sql
 ddev import-db someproject <db.sql
 gzip -dc db.sql.gz | ddev import-db`,
@@ -43,6 +52,14 @@ gzip -dc db.sql.gz | ddev import-db`,
 
 		app := projects[0]
 
+		if fromVolume != "" {
+			err = app.ImportDBFromVolume(fromVolume)
+			if err != nil {
+				util.Failed("Failed to import database from volume %s for %s: %v", fromVolume, app.GetName(), err)
+			}
+			return
+		}
+
 		if app.SiteStatus() != ddevapp.SiteRunning {
 			err = app.Start()
 			if err != nil {
@@ -50,7 +67,15 @@ gzip -dc db.sql.gz | ddev import-db`,
 			}
 		}
 
-		err = app.ImportDB(dbSource, dbExtPath, progressOption, noDrop, targetDB)
+		if forceImport {
+			err = app.ForceImportDB(dbSource, dbExtPath, progressOption, noDrop, targetDB)
+		} else {
+			err = app.ImportDB(dbSource, dbExtPath, progressOption, noDrop, targetDB)
+			if errors.Is(err, ddevapp.ErrDBImportUnchanged) {
+				util.Success("Database '%s' was last imported from %s and it hasn't changed, use --force to import it again", targetDB, dbSource)
+				return
+			}
+		}
 		if err != nil {
 			util.Failed("Failed to import database %s for %s: %v", targetDB, app.GetName(), err)
 		}
@@ -69,5 +94,7 @@ func init() {
 	ImportDBCmd.Flags().StringVarP(&targetDB, "target-db", "d", "db", "If provided, target-db is alternate database to import into")
 	ImportDBCmd.Flags().BoolVarP(&noDrop, "no-drop", "", false, "Set if you do NOT want to drop the db before importing")
 	ImportDBCmd.Flags().BoolVarP(&progressOption, "progress", "p", true, "Display a progress bar during import")
+	ImportDBCmd.Flags().BoolVarP(&forceImport, "force", "", false, "Import even if the database was last imported from the same dump")
+	ImportDBCmd.Flags().StringVarP(&fromVolume, "from-volume", "", "", "Replace the database with the database data directory in the named docker volume")
 	RootCmd.AddCommand(ImportDBCmd)
 }
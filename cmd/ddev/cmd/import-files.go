@@ -11,14 +11,18 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var sourcePath string
+var sourcePaths []string
 var extPath string
+var containerPath string
+var targetPaths []string
+var stripComponents int
 
 // ImportFileCmd represents the `ddev import-db` command.
 var ImportFileCmd = &cobra.Command{
-	Use:     "import-files",
-	Example: `ddev import-files --src=/path/to/files.tar.gz`,
-	Short:   "Pull the uploaded files directory of an existing project to the default public upload directory of your project.",
+	Use: "import-files",
+	Example: `ddev import-files --src=/path/to/files.tar.gz
+ddev import-files --src=public.tgz --target=web/sites/default/files --src=private.tgz --target=private`,
+	Short: "Pull the uploaded files directory of an existing project to the default public upload directory of your project.",
 	Long: `Pull the uploaded files directory of an existing project to the default
 public upload directory of your project. The files can be provided as a
 directory path or an archive in .tar, .tar.gz, .tgz, or .zip format. For the
@@ -29,7 +33,18 @@ imported.
 
 The destination directory can be configured in your project's config.yaml
 under the upload_dir key. If no custom upload directory is defined, the app
-type's default upload directory will be used.`,
+type's default upload directory will be used.
+
+With --container-path the files are instead extracted into that directory in
+the web container, for files that only live in the container.
+
+With --strip-components the first directories of the paths in an archive are
+dropped, so an archive with everything in a files/ directory can be imported
+with --strip-components=1.
+
+Several directories or archives can be imported at once by repeating --src.
+Each --target, relative to the project root, is the destination of the --src
+in the same position; sources without one go to the upload directory.`,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		dockerutil.EnsureDdevNetwork()
 	},
@@ -40,6 +55,15 @@ type's default upload directory will be used.`,
 			util.Failed("Failed to import files: %v", err)
 		}
 
+		if len(sourcePaths) > 1 || len(targetPaths) > 0 {
+			importMultipleFiles(app)
+			return
+		}
+
+		sourcePath := ""
+		if len(sourcePaths) == 1 {
+			sourcePath = sourcePaths[0]
+		}
 		var showExtPathPrompt bool
 		if sourcePath == "" {
 			// Ensure we prompt for extraction path if an archive is provided, while still allowing
@@ -62,7 +86,15 @@ type's default upload directory will be used.`,
 			promptForExtPath(&extPath)
 		}
 
-		if err = app.ImportFiles(importPath, extPath); err != nil {
+		if containerPath != "" {
+			if stripComponents > 0 {
+				util.Failed("--strip-components can't be used with --container-path")
+			}
+			err = app.ImportFilesToContainer(importPath, extPath, containerPath)
+		} else {
+			err = app.ImportFilesStripComponents(importPath, extPath, stripComponents)
+		}
+		if err != nil {
 			util.Failed("Failed to import files for %s: %v", app.GetName(), err)
 		}
 
@@ -70,6 +102,36 @@ type's default upload directory will be used.`,
 	},
 }
 
+// importMultipleFiles imports each --src into the --target at the same position
+func importMultipleFiles(app *ddevapp.DdevApp) {
+	if len(targetPaths) > len(sourcePaths) {
+		util.Failed("Each --target needs a --src")
+	}
+	if containerPath != "" {
+		util.Failed("--container-path can't be used with more than one --src or with --target")
+	}
+	if stripComponents > 0 {
+		util.Failed("--strip-components can't be used with more than one --src or with --target")
+	}
+	var imports []ddevapp.FilesImport
+	for i, src := range sourcePaths {
+		importPath, _, err := appimport.ValidateAsset(src, "files")
+		if err != nil {
+			util.Failed("Failed to import files for %s: %v", app.GetName(), err)
+		}
+		imp := ddevapp.FilesImport{Source: importPath, ExtractPath: extPath}
+		if i < len(targetPaths) {
+			imp.Target = targetPaths[i]
+		}
+		imports = append(imports, imp)
+	}
+	err := app.ImportFilesMultiple(imports)
+	if err != nil {
+		util.Failed("Failed to import files for %s: %v", app.GetName(), err)
+	}
+	util.Success("Successfully imported files for %v", app.GetName())
+}
+
 const importPathPrompt = `Provide the path to the source directory or archive you wish to import.`
 
 const importPathWarn = `Please note: if the destination directory exists, it will be replaced with the
@@ -105,7 +167,10 @@ func promptForExtPath(val *string) {
 }
 
 func init() {
-	ImportFileCmd.Flags().StringVarP(&sourcePath, "src", "", "", "Provide the path to the source directory or tar/tar.gz/tgz/zip archive of files to import")
+	ImportFileCmd.Flags().StringArrayVarP(&sourcePaths, "src", "", nil, "Provide the path to the source directory or tar/tar.gz/tgz/zip archive of files to import, may be repeated")
 	ImportFileCmd.Flags().StringVarP(&extPath, "extract-path", "", "", "If provided asset is an archive, optionally provide the path to extract within the archive.")
+	ImportFileCmd.Flags().StringVarP(&containerPath, "container-path", "", "", "Extract the files into this absolute path in the web container instead of the upload directory on the host")
+	ImportFileCmd.Flags().IntVarP(&stripComponents, "strip-components", "", 0, "Drop this many leading directories from the paths in the archive")
+	ImportFileCmd.Flags().StringArrayVarP(&targetPaths, "target", "", nil, "Import the --src in the same position into this directory relative to the project root instead of the upload directory, may be repeated")
 	RootCmd.AddCommand(ImportFileCmd)
 }
@@ -12,15 +12,20 @@ import (
 )
 
 var restartAll bool
+var restartRecreate bool
 
 // RestartCmd rebuilds an apps settings
 var RestartCmd = &cobra.Command{
 	Use:   "restart [projects]",
 	Short: "Restart a project or several projects.",
-	Long:  `Stops named projects and then starts them back up again.`,
+	Long: `Stops named projects and then starts them back up again.
+With --recreate, all of the project's containers are removed, even if they
+are wedged, and created again from freshly generated configuration. The
+database and other volumes are kept.`,
 	Example: `ddev restart
 ddev restart <project1> <project2>
-ddev restart --all`,
+ddev restart --all
+ddev restart --recreate`,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		dockerutil.EnsureDdevNetwork()
 	},
@@ -36,7 +41,11 @@ ddev restart --all`,
 		for _, app := range projects {
 
 			output.UserOut.Printf("Restarting project %s...", app.GetName())
-			err = app.Restart()
+			if restartRecreate {
+				err = app.Recreate()
+			} else {
+				err = app.Restart()
+			}
 			if err != nil {
 				util.Failed("Failed to restart %s: %v", app.GetName(), err)
 			}
@@ -54,5 +63,6 @@ ddev restart --all`,
 
 func init() {
 	RestartCmd.Flags().BoolVarP(&restartAll, "all", "a", false, "restart all projects")
+	RestartCmd.Flags().BoolVar(&restartRecreate, "recreate", false, "Remove and recreate all of the project's containers, keeping its volumes")
 	RootCmd.AddCommand(RestartCmd)
 }
@@ -99,12 +99,10 @@ func TestMain(m *testing.M) {
 		if err = globalconfig.ReadGlobalConfig(); err != nil {
 			log.Fatalf("Failed to read global config: %v", err)
 		}
-
-		log.Debugf("Preparing %s", TestSites[i].Name)
-		err = TestSites[i].Prepare()
-		if err != nil {
-			log.Fatalf("Prepare() failed in TestMain site=%s, err=%v\n", TestSites[i].Name, err)
-		}
+	}
+	err = testcommon.PrepareAll(TestSites)
+	if err != nil {
+		log.Fatalf("PrepareAll() failed in TestMain: %v\n", err)
 	}
 	log.Debugln("Adding TestSites")
 	err = addSites()
@@ -52,11 +52,15 @@ ddev snapshot --all`,
 }
 
 func listAppSnapshots(app *ddevapp.DdevApp) {
-	if snapshotNames, err := app.ListSnapshots(); err != nil {
+	if snapshots, err := app.Snapshots(); err != nil {
 		util.Failed("Failed to list snapshots %s: %v", app.GetName(), err)
 	} else {
-		if len(snapshotNames) > 0 {
-			util.Success("Snapshots of project %s: %s", app.GetName(), strings.Join(snapshotNames, ", "))
+		if len(snapshots) > 0 {
+			var lines []string
+			for _, s := range snapshots {
+				lines = append(lines, fmt.Sprintf("%s (%s, %.1fMB)", s.Name, s.Created.Format("2006-01-02 15:04:05"), float64(s.Size)/(1024*1024)))
+			}
+			util.Success("Snapshots of project %s:\n%s", app.GetName(), strings.Join(lines, "\n"))
 		} else {
 			util.Success("There are no snapshots for project %s", app.GetName())
 		}
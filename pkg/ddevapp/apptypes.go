@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sort"
 
+	"github.com/drud/ddev/pkg/fileutil"
 	"github.com/drud/ddev/pkg/nodeps"
 	"github.com/drud/ddev/pkg/util"
 )
@@ -185,6 +186,32 @@ func (app *DdevApp) CreateSettingsFile() (string, error) {
 	return "", nil
 }
 
+// WriteSettings regenerates the project's CMS settings files, like
+// settings.ddev.php or wp-config-ddev.php, from the current configuration,
+// for example after changing multisites, without a full Init() or Start().
+func (app *DdevApp) WriteSettings() error {
+	if app.DisableSettingsManagement {
+		return fmt.Errorf("settings files are not managed for project %s because disable_settings_management is true", app.Name)
+	}
+	if err := app.ValidateConfig(); err != nil {
+		return err
+	}
+
+	settingsPath, err := app.CreateSettingsFile()
+	if err != nil {
+		return fmt.Errorf("failed to write settings file %s: %v", app.SiteDdevSettingsFile, err)
+	}
+	if settingsPath == "" {
+		return fmt.Errorf("no settings file was written for project %s of type %s", app.Name, app.Type)
+	}
+
+	// Make sure a running web container sees the new settings right away
+	if app.IsMutagenEnabled() && app.SiteStatus() == SiteRunning {
+		return app.MutagenSyncFlush()
+	}
+	return nil
+}
+
 // GetUploadDir returns the upload (public files) directory for the given app
 func (app *DdevApp) GetUploadDir() string {
 	if appFuncs, ok := appTypeMatrix[app.GetType()]; ok && appFuncs.uploadDir != nil {
@@ -223,6 +250,26 @@ func (app *DdevApp) DetectAppType() string {
 	return nodeps.AppTypePHP
 }
 
+// DetectCMS returns the project type (like "drupal8" or "wordpress") of the
+// code in dir, the same way a new project's type is detected, or an error
+// if it isn't recognized as any CMS.
+func DetectCMS(dir string) (string, error) {
+	appRoot, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if !fileutil.IsDirectory(appRoot) {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	app := &DdevApp{AppRoot: appRoot}
+	app.Docroot = DiscoverDefaultDocroot(app)
+	appType := app.DetectAppType()
+	if appType == nodeps.AppTypePHP {
+		return "", fmt.Errorf("unable to determine the CMS type of %s", dir)
+	}
+	return appType, nil
+}
+
 // PostImportDBAction calls each apptype's detector until it finds a match,
 // or returns 'php' as a last resort.
 func (app *DdevApp) PostImportDBAction() error {
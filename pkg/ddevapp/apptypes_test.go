@@ -55,6 +55,103 @@ func TestApptypeDetection(t *testing.T) {
 	}
 }
 
+// TestDetectCMS checks DetectCMS against the TestApptypeDetection layouts
+func TestDetectCMS(t *testing.T) {
+	assert := asrt.New(t)
+	origDir, _ := os.Getwd()
+	sampleDir := filepath.Join(origDir, "testdata", "TestApptypeDetection")
+
+	for _, appType := range ddevapp.GetValidAppTypes() {
+		if appType == nodeps.AppTypePHP {
+			continue
+		}
+		foundType, err := ddevapp.DetectCMS(filepath.Join(sampleDir, appType))
+		assert.NoError(err)
+		assert.EqualValues(appType, foundType)
+	}
+
+	emptyDir := testcommon.CreateTmpDir(t.Name())
+	t.Cleanup(func() {
+		_ = os.RemoveAll(emptyDir)
+	})
+	_, err := ddevapp.DetectCMS(emptyDir)
+	assert.Error(err)
+
+	_, err = ddevapp.DetectCMS(filepath.Join(emptyDir, "nonexistent"))
+	assert.Error(err)
+}
+
+// TestInitWithType checks that an explicit project type is used for a
+// directory whose type can't be detected
+func TestInitWithType(t *testing.T) {
+	assert := asrt.New(t)
+	emptyDir := testcommon.CreateTmpDir(t.Name())
+	t.Cleanup(func() {
+		_ = os.RemoveAll(emptyDir)
+	})
+
+	app := &ddevapp.DdevApp{}
+	err := app.InitWithType(emptyDir, "notacms")
+	assert.Error(err)
+
+	err = app.InitWithType(emptyDir, nodeps.AppTypeWordPress)
+	require.NoError(t, err)
+	assert.Equal(nodeps.AppTypeWordPress, app.Type)
+	assert.Equal(filepath.Join(app.AppRoot, "wp-config.php"), app.SiteSettingsPath)
+	assert.Equal(filepath.Join(app.AppRoot, "wp-config-ddev.php"), app.SiteDdevSettingsFile)
+
+	// Without an explicit type, the empty directory is plain php
+	app = &ddevapp.DdevApp{}
+	err = app.InitWithType(emptyDir, "")
+	require.NoError(t, err)
+	assert.Equal(nodeps.AppTypePHP, app.Type)
+}
+
+// TestMagento2Conventions checks the docroot, settings file and media
+// directory of a magento2 project
+func TestMagento2Conventions(t *testing.T) {
+	assert := asrt.New(t)
+	origDir, _ := os.Getwd()
+	tmpDir := testcommon.CreateTmpDir(t.Name())
+	t.Cleanup(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	appRoot := filepath.Join(tmpDir, "magento2")
+	err := fileutil.CopyDir(filepath.Join(origDir, "testdata", "TestApptypeDetection", nodeps.AppTypeMagento2), appRoot)
+	require.NoError(t, err)
+
+	app := &ddevapp.DdevApp{}
+	err = app.InitWithType(appRoot, "")
+	require.NoError(t, err)
+	app.Name = t.Name()
+	assert.Equal(nodeps.AppTypeMagento2, app.Type)
+	assert.Equal("pub", app.Docroot)
+	assert.Equal(filepath.Join(appRoot, "pub", "media"), app.GetHostUploadDirFullPath())
+
+	err = app.WriteSettings()
+	require.NoError(t, err)
+	content, err := fileutil.ReadFileIntoString(filepath.Join(appRoot, "app", "etc", "env.php"))
+	require.NoError(t, err)
+	assert.Contains(content, `'host' => 'db'`)
+
+	// Importing files replaces pub/media
+	mediaSrc := filepath.Join(tmpDir, "media")
+	err = os.MkdirAll(filepath.Join(mediaSrc, "catalog"), 0755)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(mediaSrc, "catalog", "product.jpg"), []byte("jpg"), 0644)
+	require.NoError(t, err)
+	err = os.MkdirAll(filepath.Join(appRoot, "pub", "media"), 0755)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(appRoot, "pub", "media", "old.jpg"), []byte("old"), 0644)
+	require.NoError(t, err)
+
+	err = app.ImportFiles(mediaSrc, "")
+	require.NoError(t, err)
+	assert.FileExists(filepath.Join(appRoot, "pub", "media", "catalog", "product.jpg"))
+	assert.NoFileExists(filepath.Join(appRoot, "pub", "media", "old.jpg"))
+}
+
 // TestPostConfigAction tests that the post-config action is properly applied, but only if the
 // config is not included in the config.yaml.
 func TestPostConfigAction(t *testing.T) {
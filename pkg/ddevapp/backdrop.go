@@ -221,6 +221,10 @@ func backdropImportFilesAction(app *DdevApp, importPath, extPath string) error {
 // backdropPostStartAction handles default post-start actions for backdrop apps, like ensuring
 // useful permissions settings on sites/default.
 func backdropPostStartAction(app *DdevApp) error {
+	// Return early because we aren't expected to manage settings.
+	if app.DisableSettingsManagement {
+		return nil
+	}
 	// Drush config has to be written after start because we don't know the ports until it's started
 	err := WriteDrushrc(app, filepath.Join(filepath.Dir(app.SiteSettingsPath), "drushrc.php"))
 	if err != nil {
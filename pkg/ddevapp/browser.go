@@ -0,0 +1,58 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/drud/ddev/pkg/exec"
+	"github.com/drud/ddev/pkg/nodeps"
+	"github.com/drud/ddev/pkg/util"
+)
+
+// BrowserEnv is the environment variable that can name the command used
+// to open a browser, overriding the platform default.
+const BrowserEnv = "BROWSER"
+
+// getBrowserCommand returns the command and arguments used to open url in
+// the default browser, or an empty command if there is no way to do that,
+// for example in CI or on a Linux host without a display.
+func getBrowserCommand(url string) (string, []string) {
+	if browser := os.Getenv(BrowserEnv); browser != "" {
+		return browser, []string{url}
+	}
+	if os.Getenv("CI") != "" {
+		return "", nil
+	}
+	switch {
+	case runtime.GOOS == "darwin":
+		return "open", []string{url}
+	case runtime.GOOS == "windows":
+		return "cmd", []string{"/c", "start", url}
+	case nodeps.IsWSL2():
+		return "cmd.exe", []string{"/c", "start", url}
+	case os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "":
+		return "", nil
+	}
+	return "xdg-open", []string{url}
+}
+
+// OpenBrowser opens the project's URL() in the default browser.
+// $BROWSER, if set, is used instead of the platform's launcher. When no
+// browser can be opened it just tells the user the URL.
+func (app *DdevApp) OpenBrowser() error {
+	url := app.URL()
+	if url == "" {
+		return fmt.Errorf("unable to determine the URL of project %s", app.Name)
+	}
+	command, args := getBrowserCommand(url)
+	if command == "" {
+		util.Warning("Not opening a browser because none seems to be available, the project is at %s", url)
+		return nil
+	}
+	out, err := exec.RunHostCommand(command, args...)
+	if err != nil {
+		return fmt.Errorf("failed to open %s with %s: %v, output=%s", url, command, err, out)
+	}
+	return nil
+}
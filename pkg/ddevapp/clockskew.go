@@ -0,0 +1,53 @@
+package ddevapp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxClockSkew is how far the web container's clock may be from the host's
+// before CheckClockSkew reports it. Tokens and signed requests start
+// failing when clocks are further apart than this.
+var MaxClockSkew = 10 * time.Second
+
+// GetClockSkew returns how far the web container's clock is ahead of the
+// host's, negative if it's behind. The container's time is compared to the
+// middle of the time it took to ask for it.
+func (app *DdevApp) GetClockSkew() (time.Duration, error) {
+	before := time.Now()
+	out, _, err := app.Exec(&ExecOpts{
+		Service: "web",
+		Cmd:     "date +%s.%N",
+	})
+	after := time.Now()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get the time in the web container: %v", err)
+	}
+	secs, err := strconv.ParseFloat(strings.TrimSpace(out), 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse the time %q of the web container: %v", strings.TrimSpace(out), err)
+	}
+	containerTime := time.Unix(0, int64(secs*float64(time.Second)))
+	hostTime := before.Add(after.Sub(before) / 2)
+	return containerTime.Sub(hostTime), nil
+}
+
+// CheckClockSkew returns an error if the web container's clock is more
+// than MaxClockSkew from the host's, which happens when the docker VM's
+// clock drifts, for example after the host sleeps.
+func (app *DdevApp) CheckClockSkew() error {
+	skew, err := app.GetClockSkew()
+	if err != nil {
+		return err
+	}
+	direction := "ahead of"
+	if skew < 0 {
+		skew, direction = -skew, "behind"
+	}
+	if skew > MaxClockSkew {
+		return fmt.Errorf("the web container's clock is %s %s the host's, which can break logins and API tokens; restarting docker usually fixes its clock", skew.Round(time.Second), direction)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package ddevapp
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/drud/ddev/pkg/nodeps"
+)
+
+// CMSCLI returns the CMS command-line tool for the project type, like drush
+// or wp, and the directory in the web container it should be run in.
+func (app *DdevApp) CMSCLI() (string, string, error) {
+	var cli string
+	switch app.Type {
+	case nodeps.AppTypeDrupal6, nodeps.AppTypeDrupal7, nodeps.AppTypeDrupal8, nodeps.AppTypeDrupal9, nodeps.AppTypeDrupal10, nodeps.AppTypeBackdrop:
+		cli = "drush"
+	case nodeps.AppTypeWordPress:
+		cli = "wp"
+	default:
+		return "", "", fmt.Errorf("there is no CMS command-line tool for project type %s", app.Type)
+	}
+	return cli, path.Join(app.ContainerWorkingDir(), app.Docroot), nil
+}
+
+// RunCMSCLI runs the project's CMS command-line tool (see CMSCLI()) with
+// args in the docroot of the web container and returns its output.
+func (app *DdevApp) RunCMSCLI(args []string) (string, error) {
+	cli, dir, err := app.CMSCLI()
+	if err != nil {
+		return "", err
+	}
+	quotedArgs := []string{cli}
+	for _, arg := range args {
+		quotedArgs = append(quotedArgs, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	stdout, stderr, err := app.Exec(&ExecOpts{
+		Service: "web",
+		Dir:     dir,
+		Cmd:     strings.Join(quotedArgs, " "),
+	})
+	if err != nil {
+		return stdout, fmt.Errorf("%s failed: %v, stderr=%s", cli, err, stderr)
+	}
+	return stdout, nil
+}
@@ -5,10 +5,37 @@ import (
 	"github.com/drud/ddev/pkg/fileutil"
 	"github.com/mattn/go-isatty"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 )
 
+// GetHostComposerCacheDir returns the location of the host's composer cache,
+// using the same rules as composer itself: $COMPOSER_CACHE_DIR if it is set,
+// otherwise the platform's default cache location.
+// It does not check that the directory exists.
+func GetHostComposerCacheDir() string {
+	if dir := os.Getenv("COMPOSER_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
+			return filepath.Join(localAppData, "Composer")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library", "Caches", "composer")
+	}
+	if xdgCache := os.Getenv("XDG_CACHE_HOME"); xdgCache != "" {
+		return filepath.Join(xdgCache, "composer")
+	}
+	return filepath.Join(home, ".cache", "composer")
+}
+
 // Composer runs composer commands in the web container, managing pre- and post- hooks
 // returns stdout, stderr, error
 func (app *DdevApp) Composer(args []string) (string, string, error) {
@@ -0,0 +1,70 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/drud/ddev/pkg/fileutil"
+	"github.com/drud/ddev/pkg/util"
+	"gopkg.in/yaml.v2"
+)
+
+// generatedComposeFiles returns the paths of the docker-compose files ddev
+// generates for the project
+func (app *DdevApp) generatedComposeFiles() []string {
+	return []string{app.DockerComposeYAMLPath(), app.DockerComposeFullRenderedYAMLPath()}
+}
+
+// checkGeneratedComposeFile returns an error wrapping ErrCorruptCompose if
+// the generated compose file at path isn't yaml with services in it
+func checkGeneratedComposeFile(path string) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	compose := map[string]interface{}{}
+	if err = yaml.Unmarshal(contents, &compose); err != nil {
+		return fmt.Errorf("%w: %s can't be parsed: %v", ErrCorruptCompose, RenderHomeRootedDir(path), err)
+	}
+	if _, ok := compose["services"]; !ok {
+		return fmt.Errorf("%w: %s has no services", ErrCorruptCompose, RenderHomeRootedDir(path))
+	}
+	return nil
+}
+
+// CheckGeneratedCompose checks that the generated docker-compose files of
+// the project that exist can be parsed. They are regenerated on start, but
+// a broken one can keep ddev from stopping or updating the project first.
+func (app *DdevApp) CheckGeneratedCompose() error {
+	for _, f := range app.generatedComposeFiles() {
+		if !fileutil.FileExists(f) {
+			continue
+		}
+		if err := checkGeneratedComposeFile(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RepairCompose regenerates the project's generated docker-compose files
+// from its config. Files that can't be parsed are kept with a .broken
+// suffix, so hand edits to them aren't lost.
+func (app *DdevApp) RepairCompose() error {
+	for _, f := range app.generatedComposeFiles() {
+		if !fileutil.FileExists(f) || checkGeneratedComposeFile(f) == nil {
+			continue
+		}
+		backup := f + ".broken"
+		if err := os.Rename(f, backup); err != nil {
+			return fmt.Errorf("failed to back up %s: %v", f, err)
+		}
+		util.Warning("The broken %s was moved to %s", RenderHomeRootedDir(f), RenderHomeRootedDir(backup))
+	}
+	app.ComposeYaml = nil
+	app.DockerEnv()
+	if err := app.WriteDockerComposeYAML(); err != nil {
+		return fmt.Errorf("failed to regenerate the docker-compose files of project %s: %v", app.Name, err)
+	}
+	return app.CheckGeneratedCompose()
+}
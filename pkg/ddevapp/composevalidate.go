@@ -0,0 +1,57 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+)
+
+// ValidateCompose renders the project's .ddev-docker-compose-base.yaml and
+// checks it, together with the project's docker-compose.*.yaml files,
+// with "docker-compose config". If docker-compose rejects them the error
+// wraps ErrInvalidCompose and lists the problems it found.
+func (app *DdevApp) ValidateCompose() error {
+	app.DockerEnv()
+	err := os.MkdirAll(app.GetGeneratedComposeDir(), 0755)
+	if err != nil {
+		return err
+	}
+	err = app.writeComposeBaseYAML()
+	if err != nil {
+		return err
+	}
+	files, err := app.ComposeFiles()
+	if err != nil {
+		return err
+	}
+	_, stderr, err := dockerutil.ComposeCmd(files, app.composeConfigArgs("--quiet")...)
+	if err == nil {
+		return nil
+	}
+	problems := app.composeConfigProblems(stderr)
+	if len(problems) == 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidCompose, err)
+	}
+	return fmt.Errorf("%w in %s: %s", ErrInvalidCompose, RenderHomeRootedDir(app.AppConfDir()), strings.Join(problems, "; "))
+}
+
+// composeConfigProblems extracts the problems docker-compose config reported
+// from its stderr, with the paths of the files made relative to the
+// project's .ddev directory.
+func (app *DdevApp) composeConfigProblems(stderr string) []string {
+	problems := []string{}
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"ERROR: ", "error: ", "Error: "} {
+			line = strings.TrimPrefix(line, prefix)
+		}
+		if line == "" || strings.HasPrefix(line, "WARNING:") || strings.Contains(line, "level=warning") {
+			continue
+		}
+		line = strings.ReplaceAll(line, app.AppConfDir()+"/", "")
+		problems = append(problems, line)
+	}
+	return problems
+}
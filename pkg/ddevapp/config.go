@@ -2,14 +2,19 @@ package ddevapp
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"github.com/Masterminds/sprig/v3"
 	"github.com/drud/ddev/pkg/dockerutil"
 	"github.com/drud/ddev/pkg/globalconfig"
 	"github.com/drud/ddev/pkg/nodeps"
+	"net"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -125,9 +130,11 @@ func NewApp(appRoot string, includeOverrides bool) (*DdevApp, error) {
 		if err != nil {
 			return app, err
 		}
+		// A broken rendered file is reported by Start and fixed by RepairCompose
 		err = app.UpdateComposeYaml(content)
 		if err != nil {
-			return app, err
+			app.ComposeYaml = nil
+			util.Warning("Unable to parse %s, 'ddev debug repair-compose' regenerates it: %v", app.DockerComposeFullRenderedYAMLPath(), err)
 		}
 
 		_, err = app.ReadConfig(includeOverrides)
@@ -456,6 +463,101 @@ func (app *DdevApp) ValidateConfig() error {
 		return fmt.Errorf("both mariadb_version (%v) and mysql_version (%v) are set, but they are mutually exclusive", app.MariaDBVersion, app.MySQLVersion)
 	}
 
+	if app.NodeJSVersion != "" && !nodeps.IsValidNodeJSVersion(app.NodeJSVersion) {
+		return fmt.Errorf("unsupported nodejs_version: %s, ddev only supports the following nodejs versions: %s", app.NodeJSVersion, nodeps.GetValidNodeJSVersions())
+	}
+
+	if err := validateResourceLimits(app); err != nil {
+		return err
+	}
+
+	for name, d := range map[string]string{"healthcheck_interval": app.HealthcheckInterval, "healthcheck_timeout": app.HealthcheckTimeout, "stop_grace_period": app.StopGracePeriod, "import_timeout": app.ImportTimeout} {
+		if d == "" {
+			continue
+		}
+		if duration, err := time.ParseDuration(d); err != nil || duration <= 0 {
+			return fmt.Errorf("invalid %s %q: it must be a duration like 5s or 2m", name, d)
+		}
+	}
+	if app.RestartPolicy != "" && !nodeps.ArrayContainsString(ValidRestartPolicies, app.RestartPolicy) {
+		return fmt.Errorf("invalid restart_policy %q: it must be one of %s", app.RestartPolicy, strings.Join(ValidRestartPolicies, ", "))
+	}
+	if app.ExistingContainers != "" && !nodeps.ArrayContainsString(ValidExistingContainersPolicies, app.ExistingContainers) {
+		return fmt.Errorf("invalid existing_containers %q: it must be one of %s", app.ExistingContainers, strings.Join(ValidExistingContainersPolicies, ", "))
+	}
+	if err := app.validateMountType(); err != nil {
+		return err
+	}
+	if err := app.validateLogSettings(); err != nil {
+		return err
+	}
+	if err := app.validateXdebugSettings(); err != nil {
+		return err
+	}
+	if err := app.validateWebserverTuning(); err != nil {
+		return err
+	}
+	if err := app.validateWebCommand(); err != nil {
+		return err
+	}
+	if err := app.validateImageRegistry(); err != nil {
+		return err
+	}
+	if err := app.validateExtraHosts(); err != nil {
+		return err
+	}
+	if err := app.validateImportFilesOwner(); err != nil {
+		return err
+	}
+	if err := app.validateDNSSettings(); err != nil {
+		return err
+	}
+	if app.GeneratedComposeDir != "" && fileutil.FileExists(app.GetGeneratedComposeDir()) && !fileutil.IsDirectory(app.GetGeneratedComposeDir()) {
+		return fmt.Errorf("generated_compose_dir %s is not a directory", app.GetGeneratedComposeDir())
+	}
+	if !app.IsDBPortExposed() && app.HostDBPort != "" {
+		return fmt.Errorf("host_db_port %s can't be used with expose_db_port: false", app.HostDBPort)
+	}
+	if app.HealthcheckRetries < 0 {
+		return fmt.Errorf("invalid healthcheck_retries %d: it can't be negative", app.HealthcheckRetries)
+	}
+
+	if app.DockerNetworkName != "" && !networkNameRegex.MatchString(app.DockerNetworkName) {
+		return fmt.Errorf("invalid network_name %q: it may only contain letters, digits, '_', '.' and '-'", app.DockerNetworkName)
+	}
+
+	if err := app.validateMultisites(); err != nil {
+		return err
+	}
+
+	if app.BasePath != "" && (!strings.HasPrefix(app.BasePath, "/") || strings.HasSuffix(app.BasePath, "/") || strings.ContainsAny(app.BasePath, " \t$")) {
+		return fmt.Errorf("invalid base_path %q: it must start with a '/', must not end with one and must not contain whitespace, for example base_path: /app", app.BasePath)
+	}
+
+	if app.DBLongQueryTime < 0 {
+		return fmt.Errorf("invalid db_long_query_time %v: it can't be negative", app.DBLongQueryTime)
+	}
+	if app.DBLongQueryTime > 0 && globalconfig.DdevGlobalConfig.NoBindMounts {
+		return fmt.Errorf("db_long_query_time is not supported with no_bind_mounts")
+	}
+
+	if app.WebEnvFile != "" && !fileutil.FileExists(app.GetWebEnvFile()) {
+		return fmt.Errorf("the web_env_file %s does not exist", app.GetWebEnvFile())
+	}
+
+	if err := app.validateDocrootReadOnly(); err != nil {
+		return err
+	}
+
+	if app.DBReplicaEnabled {
+		if nodeps.ArrayContainsString(app.GetOmittedContainers(), nodeps.DBContainer) {
+			return fmt.Errorf("db_replica_enabled requires the db container, but it is in omit_containers")
+		}
+		if globalconfig.DdevGlobalConfig.NoBindMounts {
+			return fmt.Errorf("db_replica_enabled is not supported with no_bind_mounts")
+		}
+	}
+
 	// golang on windows is not able to time.LoadLocation unless
 	// go is installed... so skip validation on Windows
 	if runtime.GOOS != "windows" {
@@ -474,13 +576,36 @@ func (app *DdevApp) ValidateConfig() error {
 // DockerComposeYAMLPath returns the absolute path to where the
 // base generated yaml file should exist for this project.
 func (app *DdevApp) DockerComposeYAMLPath() string {
-	return app.GetConfigPath(".ddev-docker-compose-base.yaml")
+	return filepath.Join(app.GetGeneratedComposeDir(), ".ddev-docker-compose-base.yaml")
 }
 
 // DockerComposeFullRenderedYAMLPath returns the absolute path to where the
 // the complete generated yaml file should exist for this project.
 func (app *DdevApp) DockerComposeFullRenderedYAMLPath() string {
-	return app.GetConfigPath(".ddev-docker-compose-full.yaml")
+	return filepath.Join(app.GetGeneratedComposeDir(), ".ddev-docker-compose-full.yaml")
+}
+
+// GetGeneratedComposeDir returns the directory the generated docker-compose
+// files are written to: generated_compose_dir, relative to the project
+// root, or the .ddev directory.
+func (app *DdevApp) GetGeneratedComposeDir() string {
+	if app.GeneratedComposeDir == "" {
+		return app.AppConfDir()
+	}
+	if filepath.IsAbs(app.GeneratedComposeDir) {
+		return filepath.Clean(app.GeneratedComposeDir)
+	}
+	return filepath.Join(app.AppRoot, app.GeneratedComposeDir)
+}
+
+// composeConfigArgs returns the docker-compose arguments that render the
+// project's compose files into one. Relative paths in the files are
+// relative to the .ddev directory, even if the generated files aren't in it.
+func (app *DdevApp) composeConfigArgs(args ...string) []string {
+	if app.GetGeneratedComposeDir() == app.AppConfDir() {
+		return append([]string{"config"}, args...)
+	}
+	return append([]string{"--project-directory", app.AppConfDir(), "config"}, args...)
 }
 
 // GetHostname returns the primary hostname of the app.
@@ -507,6 +632,10 @@ func (app *DdevApp) GetHostnames() []string {
 			nameListMap[name] = 1
 		}
 
+		for _, site := range app.GetMultisites() {
+			nameListMap[app.MultisiteHostname(site)] = 1
+		}
+
 		// Make sure the primary hostname didn't accidentally get added, it will be prepended
 		delete(nameListMap, app.GetHostname())
 
@@ -521,21 +650,52 @@ func (app *DdevApp) GetHostnames() []string {
 	return nameListArray
 }
 
+// AddHostname adds a hostname the project is served on and saves it in
+// config.yaml. A name in the project_tld, or without any dots, goes in
+// additional_hostnames, anything else in additional_fqdns. Like the other
+// hostnames it is added to the hosts file if needed and set up in the
+// router and web server the next time the project starts.
+func (app *DdevApp) AddHostname(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if nodeps.ArrayContainsString(app.GetHostnames(), name) {
+		return nil
+	}
+	origHostnames, origFQDNs := app.AdditionalHostnames, app.AdditionalFQDNs
+	if short := strings.TrimSuffix(name, "."+app.ProjectTLD); short != name || !strings.Contains(name, ".") {
+		app.AdditionalHostnames = append(app.AdditionalHostnames, short)
+	} else {
+		app.AdditionalFQDNs = append(app.AdditionalFQDNs, name)
+	}
+	if err := app.ValidateConfig(); err != nil {
+		app.AdditionalHostnames, app.AdditionalFQDNs = origHostnames, origFQDNs
+		return err
+	}
+	return app.WriteConfig()
+}
+
+// composeYAMLWriteError explains a failure to write a generated
+// docker-compose file, which is usually a permissions problem.
+func composeYAMLWriteError(path string, err error) error {
+	if os.IsPermission(err) {
+		return fmt.Errorf("unable to write %s, please make sure %s is writable by you: %w", path, filepath.Dir(path), err)
+	}
+	return fmt.Errorf("unable to write %s: %w", path, err)
+}
+
 // WriteDockerComposeYAML writes a .ddev-docker-compose-base.yaml and related to the .ddev directory.
 func (app *DdevApp) WriteDockerComposeYAML() error {
 	var err error
 
-	f, err := os.Create(app.DockerComposeYAMLPath())
+	err = os.MkdirAll(app.AppConfDir(), 0755)
 	if err != nil {
-		return err
+		return fmt.Errorf("unable to create the project configuration directory %s: %v", app.AppConfDir(), err)
 	}
-	defer util.CheckClose(f)
-
-	rendered, err := app.RenderComposeYAML()
+	err = os.MkdirAll(app.GetGeneratedComposeDir(), 0755)
 	if err != nil {
-		return err
+		return fmt.Errorf("unable to create the generated_compose_dir %s: %v", app.GetGeneratedComposeDir(), err)
 	}
-	_, err = f.WriteString(rendered)
+
+	err = app.writeComposeBaseYAML()
 	if err != nil {
 		return err
 	}
@@ -544,7 +704,7 @@ func (app *DdevApp) WriteDockerComposeYAML() error {
 	if err != nil {
 		return err
 	}
-	fullContents, _, err := dockerutil.ComposeCmd(files, "config")
+	fullContents, _, err := dockerutil.ComposeCmd(files, app.composeConfigArgs()...)
 	if err != nil {
 		return err
 	}
@@ -554,14 +714,18 @@ func (app *DdevApp) WriteDockerComposeYAML() error {
 	// Note that this issue with docker-compose config was fixed in docker-compose 2.0.0RC4
 	// so it's in Docker Desktop 4.1.0.
 	// https://github.com/docker/compose/issues/8503#issuecomment-930969241
-	fullContents = strings.Replace(fullContents, fmt.Sprintf("source: %s\n", app.AppRoot), "source: ../\n", -1)
+	// The relative path only works from the .ddev directory.
+	if app.GetGeneratedComposeDir() == app.AppConfDir() {
+		fullContents = strings.Replace(fullContents, fmt.Sprintf("source: %s\n", app.AppRoot), "source: ../\n", -1)
+	}
 	fullHandle, err := os.Create(app.DockerComposeFullRenderedYAMLPath())
 	if err != nil {
-		return err
+		return composeYAMLWriteError(app.DockerComposeFullRenderedYAMLPath(), err)
 	}
+	defer util.CheckClose(fullHandle)
 	_, err = fullHandle.WriteString(fullContents)
 	if err != nil {
-		return err
+		return composeYAMLWriteError(app.DockerComposeFullRenderedYAMLPath(), err)
 	}
 
 	err = app.UpdateComposeYaml(fullContents)
@@ -571,6 +735,502 @@ func (app *DdevApp) WriteDockerComposeYAML() error {
 	return nil
 }
 
+// writeComposeBaseYAML renders the project's .ddev-docker-compose-base.yaml
+func (app *DdevApp) writeComposeBaseYAML() error {
+	f, err := os.Create(app.DockerComposeYAMLPath())
+	if err != nil {
+		return composeYAMLWriteError(app.DockerComposeYAMLPath(), err)
+	}
+	defer util.CheckClose(f)
+
+	rendered, err := app.RenderComposeYAML()
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(rendered)
+	if err != nil {
+		return composeYAMLWriteError(app.DockerComposeYAMLPath(), err)
+	}
+	return nil
+}
+
+// GetAdditionalMountSource returns the absolute host path of an additional mount
+func (app *DdevApp) GetAdditionalMountSource(m AdditionalMount) string {
+	if filepath.IsAbs(m.Source) {
+		return m.Source
+	}
+	return filepath.Join(app.AppRoot, m.Source)
+}
+
+// WebEntrypointScript is the script in .ddev that's run in the web container
+// each time it starts, before the web server and php-fpm are started
+const WebEntrypointScript = "web-entrypoint.sh"
+
+// validateWebCommand checks that web_command can be used.
+func (app *DdevApp) validateWebCommand() error {
+	if app.WebCommand == "" {
+		return nil
+	}
+	if strings.TrimSpace(app.WebCommand) == "" {
+		return fmt.Errorf("web_command can't be only whitespace")
+	}
+	if strings.ContainsAny(app.WebCommand, "\n\r") {
+		return fmt.Errorf("web_command must be a single line; put longer scripts in a file and run that")
+	}
+	return nil
+}
+
+// validateExtraHosts checks that each of extra_hosts is hostname:ip.
+func (app *DdevApp) validateExtraHosts() error {
+	for _, h := range app.ExtraHosts {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 || !hostRegex.MatchString(parts[0]) || net.ParseIP(parts[1]) == nil {
+			return fmt.Errorf("invalid extra_hosts entry %q: it must be hostname:ip, like myservice:10.0.0.5", h)
+		}
+	}
+	return nil
+}
+
+// validateDNSSettings checks that dns are IP addresses and dns_search
+// are domain names.
+func (app *DdevApp) validateDNSSettings() error {
+	for _, server := range app.DNS {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf("invalid dns server %q: it must be an IP address, like 10.0.0.2", server)
+		}
+	}
+	for _, domain := range app.DNSSearch {
+		if !hostRegex.MatchString(domain) {
+			return fmt.Errorf("invalid dns_search domain %q: it must be a domain name, like corp.example.com", domain)
+		}
+	}
+	return nil
+}
+
+// getExtraHosts returns the hostname:ip entries added to the web
+// container's /etc/hosts: host.docker.internal, if its address is known
+// and extra_hosts doesn't set it, and extra_hosts.
+func (app *DdevApp) getExtraHosts(hostDockerInternalIP string) []string {
+	extraHosts := []string{}
+	hostDockerInternalSet := false
+	for _, h := range app.ExtraHosts {
+		if strings.HasPrefix(h, "host.docker.internal:") {
+			hostDockerInternalSet = true
+		}
+	}
+	if hostDockerInternalIP != "" && !hostDockerInternalSet {
+		extraHosts = append(extraHosts, "host.docker.internal:"+hostDockerInternalIP)
+	}
+	return append(extraHosts, app.ExtraHosts...)
+}
+
+// ValidRestartPolicies are the values restart_policy can have
+var ValidRestartPolicies = []string{"no", "unless-stopped", "always"}
+
+// GetRestartPolicy returns the docker restart policy of the project's
+// containers. Without restart_policy it's "always" if the global
+// auto_restart_containers is set and otherwise "no".
+func (app *DdevApp) GetRestartPolicy() string {
+	if app.RestartPolicy != "" {
+		return app.RestartPolicy
+	}
+	if globalconfig.DdevGlobalConfig.AutoRestartContainers {
+		return "always"
+	}
+	return "no"
+}
+
+// What Start does with the project's existing containers, from
+// existing_containers
+const (
+	// ExistingContainersReuse starts existing containers again, unless
+	// they were created with another configuration
+	ExistingContainersReuse = "reuse"
+	// ExistingContainersRecreate always replaces them with new ones
+	ExistingContainersRecreate = "recreate"
+)
+
+// ValidExistingContainersPolicies are the values existing_containers can have
+var ValidExistingContainersPolicies = []string{ExistingContainersReuse, ExistingContainersRecreate}
+
+// GetExistingContainersPolicy returns what Start does with containers that
+// already exist, from existing_containers. The default is to reuse them.
+func (app *DdevApp) GetExistingContainersPolicy() string {
+	if app.ExistingContainers == "" {
+		return ExistingContainersReuse
+	}
+	return app.ExistingContainers
+}
+
+// How the project is mounted into the web container, from mount_type.
+// The consistency of bind mounts only makes a difference with Docker
+// Desktop for Mac; other docker providers ignore it.
+const (
+	// MountTypeBind is a plain bind mount, where the container always
+	// sees the host's files as they are, which is slow on macOS
+	MountTypeBind = "bind"
+	// MountTypeCached is a bind mount where the host's view is
+	// authoritative and the container's may lag behind it
+	MountTypeCached = "cached"
+	// MountTypeDelegated is a bind mount where the container's view is
+	// authoritative and the host's may lag behind it
+	MountTypeDelegated = "delegated"
+	// MountTypeSync syncs the project into a docker volume with mutagen,
+	// like mutagen_enabled
+	MountTypeSync = "sync"
+)
+
+// ValidMountTypes are the values mount_type can have
+var ValidMountTypes = []string{MountTypeBind, MountTypeCached, MountTypeDelegated, MountTypeSync}
+
+// validateMountType checks mount_type. Only sync can be used when mutagen
+// or NFS is enabled, in the project or globally, since they replace the
+// bind mount the other types configure. Mutagen turns NFS off.
+func (app *DdevApp) validateMountType() error {
+	if app.MountType == "" || app.MountType == MountTypeSync {
+		return nil
+	}
+	if !nodeps.ArrayContainsString(ValidMountTypes, app.MountType) {
+		return fmt.Errorf("invalid mount_type %q: it must be one of %s", app.MountType, strings.Join(ValidMountTypes, ", "))
+	}
+	if app.IsMutagenEnabled() {
+		return fmt.Errorf("mount_type %s can't be used with mutagen, which syncs the project instead of bind-mounting it", app.MountType)
+	}
+	if app.NFSMountEnabled || app.NFSMountEnabledGlobal {
+		return fmt.Errorf("mount_type %s can't be used with NFS, which mounts the project instead", app.MountType)
+	}
+	return nil
+}
+
+// GetMountConsistency returns the consistency of the project's bind mount
+// for mount_type. The default is cached.
+func (app *DdevApp) GetMountConsistency() string {
+	switch app.MountType {
+	case MountTypeBind:
+		return "consistent"
+	case MountTypeDelegated:
+		return "delegated"
+	}
+	return "cached"
+}
+
+// Default logging of the project's containers, so that their logs
+// can't fill up the disk
+const (
+	DefaultLogDriver  = "json-file"
+	DefaultLogMaxSize = "10m"
+	DefaultLogMaxFile = 5
+)
+
+// logDriversWithRotation are the docker logging drivers that take the
+// max-size and max-file options
+var logDriversWithRotation = []string{"json-file", "local"}
+
+var logMaxSizeRegex = regexp.MustCompile(`^[1-9][0-9]*[kmg]?$`)
+
+// GetLogSettings returns the docker logging driver of the project's
+// containers and its max-size and max-file options, from log_driver,
+// log_max_size and log_max_file or the defaults. The options are "" for
+// drivers that don't rotate logs.
+func (app *DdevApp) GetLogSettings() (string, string, string) {
+	driver := DefaultLogDriver
+	if app.LogDriver != "" {
+		driver = app.LogDriver
+	}
+	if !nodeps.ArrayContainsString(logDriversWithRotation, driver) {
+		return driver, "", ""
+	}
+	maxSize, maxFile := DefaultLogMaxSize, DefaultLogMaxFile
+	if app.LogMaxSize != "" {
+		maxSize = app.LogMaxSize
+	}
+	if app.LogMaxFile > 0 {
+		maxFile = app.LogMaxFile
+	}
+	return driver, maxSize, strconv.Itoa(maxFile)
+}
+
+// validateLogSettings checks log_driver, log_max_size and log_max_file.
+func (app *DdevApp) validateLogSettings() error {
+	if strings.ContainsAny(app.LogDriver, " \t\"'") {
+		return fmt.Errorf("invalid log_driver %q", app.LogDriver)
+	}
+	if app.LogMaxSize != "" && !logMaxSizeRegex.MatchString(app.LogMaxSize) {
+		return fmt.Errorf("invalid log_max_size %q: it must be a size like 10m, 500k or 1g", app.LogMaxSize)
+	}
+	if app.LogMaxFile < 0 {
+		return fmt.Errorf("invalid log_max_file %d: it can't be negative", app.LogMaxFile)
+	}
+	if app.LogDriver != "" && !nodeps.ArrayContainsString(logDriversWithRotation, app.LogDriver) && (app.LogMaxSize != "" || app.LogMaxFile > 0) {
+		return fmt.Errorf("log_max_size and log_max_file can only be used with the log_driver %s", strings.Join(logDriversWithRotation, " or "))
+	}
+	return nil
+}
+
+// DefaultXdebugPort is the port on the host that xdebug connects to
+// unless xdebug_port is set
+const DefaultXdebugPort = 9003
+
+var xdebugIDEKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// validateXdebugSettings checks xdebug_port and xdebug_ide_key.
+func (app *DdevApp) validateXdebugSettings() error {
+	if app.XdebugPort < 0 || app.XdebugPort > 65535 {
+		return fmt.Errorf("invalid xdebug_port %d: it must be a port number between 1 and 65535", app.XdebugPort)
+	}
+	if app.XdebugIDEKey != "" && !xdebugIDEKeyRegex.MatchString(app.XdebugIDEKey) {
+		return fmt.Errorf("invalid xdebug_ide_key %q: it may only contain letters, digits, '_', '.' and '-'", app.XdebugIDEKey)
+	}
+	return nil
+}
+
+// xdebugIniCommands returns the Dockerfile commands that put xdebug_port
+// and xdebug_ide_key into the web image's xdebug.ini files, so they apply
+// whenever xdebug is enabled. Xdebug 2, used with PHP < 7.2, calls the
+// port remote_port, xdebug 3 calls it client_port. It's "" if neither is set.
+func (app *DdevApp) xdebugIniCommands() string {
+	var commands []string
+	if app.XdebugPort != 0 && app.XdebugPort != DefaultXdebugPort {
+		commands = append(commands, fmt.Sprintf(`sed -i -E 's/^xdebug\.(client|remote)_port=.*/xdebug.\1_port=%d/' /etc/php/*/mods-available/xdebug.ini`, app.XdebugPort))
+	}
+	if app.XdebugIDEKey != "" {
+		commands = append(commands, fmt.Sprintf(`for f in /etc/php/*/mods-available/xdebug.ini; do echo "xdebug.idekey=%s" >>$f; done`, app.XdebugIDEKey))
+	}
+	if len(commands) == 0 {
+		return ""
+	}
+	return "RUN " + strings.Join(commands, " && ") + "\n"
+}
+
+// nginxSizeRegex matches nginx buffer sizes like 32k or 1m
+var nginxSizeRegex = regexp.MustCompile(`^[1-9][0-9]*[kKmM]?$`)
+
+// validateWebserverTuning checks webserver_workers, fastcgi_buffers and
+// fastcgi_buffer_size.
+func (app *DdevApp) validateWebserverTuning() error {
+	if app.WebserverWorkers < 0 {
+		return fmt.Errorf("invalid webserver_workers %d: it must be a positive number of processes", app.WebserverWorkers)
+	}
+	if app.FastCGIBuffers != "" {
+		parts := strings.Fields(app.FastCGIBuffers)
+		if len(parts) != 2 || !nodeps.IsInteger(parts[0]) || !nginxSizeRegex.MatchString(parts[1]) {
+			return fmt.Errorf("invalid fastcgi_buffers %q: it must be a number and a size, like \"16 16k\"", app.FastCGIBuffers)
+		}
+	}
+	if app.FastCGIBufferSize != "" && !nginxSizeRegex.MatchString(app.FastCGIBufferSize) {
+		return fmt.Errorf("invalid fastcgi_buffer_size %q: it must be a size like 32k", app.FastCGIBufferSize)
+	}
+	return nil
+}
+
+// GetFastCGIBufferSettings returns the nginx fastcgi_buffers and
+// fastcgi_buffer_size of the project, or the defaults.
+func (app *DdevApp) GetFastCGIBufferSettings() (string, string) {
+	buffers, bufferSize := "16 16k", "32k"
+	if app.FastCGIBuffers != "" {
+		buffers = strings.Join(strings.Fields(app.FastCGIBuffers), " ")
+	}
+	if app.FastCGIBufferSize != "" {
+		bufferSize = app.FastCGIBufferSize
+	}
+	return buffers, bufferSize
+}
+
+// webserverWorkersCommands returns the Dockerfile commands that set the
+// number of nginx worker processes and apache event MPM server processes
+// in the web image to webserver_workers. Apache's MaxRequestWorkers is
+// raised to match, with its default 25 threads per process. It's "" if
+// webserver_workers isn't set.
+func (app *DdevApp) webserverWorkersCommands() string {
+	if app.WebserverWorkers <= 0 {
+		return ""
+	}
+	n := app.WebserverWorkers
+	return fmt.Sprintf(`RUN sed -i -E 's/^worker_processes .*/worker_processes %d;/' /etc/nginx/nginx.conf && sed -i -E 's/^(\s*)StartServers\s.*/\1StartServers %d\n\1ServerLimit %d/; s/^(\s*)MaxRequestWorkers\s.*/\1MaxRequestWorkers %d/' /etc/apache2/mods-available/mpm_event.conf`, n, n, n, n*25) + "\n"
+}
+
+// GetStopGracePeriod returns how many seconds containers are given to shut
+// down cleanly on stop before they are killed, from stop_grace_period.
+// It's 0 if stop_grace_period isn't set, leaving the docker default.
+func (app *DdevApp) GetStopGracePeriod() uint {
+	if app.StopGracePeriod == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(app.StopGracePeriod)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	// Round up, so that something like 500ms still gets a second
+	return uint((d + time.Second - 1) / time.Second)
+}
+
+// GetWebEnvFile returns the host path of the env file whose variables are
+// put into the web container's environment: web_env_file, relative to the
+// project root, or the project's .env if there is one. It's "" if there's none.
+// Laravel and Shopware 6 read .env themselves, and ddev writes it for them
+// after start, so theirs is only used if it's configured as web_env_file.
+func (app *DdevApp) GetWebEnvFile() string {
+	if app.WebEnvFile != "" {
+		if filepath.IsAbs(app.WebEnvFile) {
+			return app.WebEnvFile
+		}
+		return filepath.Join(app.AppRoot, app.WebEnvFile)
+	}
+	if nodeps.ArrayContainsString([]string{nodeps.AppTypeLaravel, nodeps.AppTypeShopware6}, app.Type) {
+		return ""
+	}
+	envFile := filepath.Join(app.AppRoot, ".env")
+	if fileutil.FileExists(envFile) && !fileutil.IsDirectory(envFile) {
+		return envFile
+	}
+	return ""
+}
+
+// GetHealthcheckSettings returns the interval, retries and timeout of the
+// container healthchecks, from healthcheck_interval, healthcheck_retries and
+// healthcheck_timeout or the defaults.
+func (app *DdevApp) GetHealthcheckSettings() (string, int, string) {
+	interval, retries, timeout := "1s", 120, "120s"
+	if app.HealthcheckInterval != "" {
+		interval = app.HealthcheckInterval
+	}
+	if app.HealthcheckRetries > 0 {
+		retries = app.HealthcheckRetries
+	}
+	if app.HealthcheckTimeout != "" {
+		timeout = app.HealthcheckTimeout
+	}
+	return interval, retries, timeout
+}
+
+// GetImportTimeout returns how long a database import may take, from
+// import_timeout. It's 0, meaning no limit, if that isn't set.
+func (app *DdevApp) GetImportTimeout() time.Duration {
+	if app.ImportTimeout == "" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(app.ImportTimeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// networkNameRegex matches valid docker network names
+var networkNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// memoryLimitRegex matches the memory sizes docker accepts, like 512m or 2g
+var memoryLimitRegex = regexp.MustCompile(`^[0-9]+[bkmg]?$`)
+
+// validateResourceLimits checks the web and db memory and cpu limits
+func validateResourceLimits(app *DdevApp) error {
+	for name, limit := range map[string]string{"web_memory_limit": app.WebMemoryLimit, "db_memory_limit": app.DBMemoryLimit} {
+		if limit == "" {
+			continue
+		}
+		if !memoryLimitRegex.MatchString(strings.ToLower(limit)) {
+			return fmt.Errorf("invalid %s %q: it must be a size like 512m or 2g", name, limit)
+		}
+	}
+	for name, limit := range map[string]string{"web_cpu_limit": app.WebCPULimit, "db_cpu_limit": app.DBCPULimit} {
+		if limit == "" {
+			continue
+		}
+		// The cpus docker has are those of its VM with Docker Desktop or
+		// colima, not the host's, so docker checks the upper limit on start.
+		cpus, err := strconv.ParseFloat(limit, 64)
+		if err != nil || cpus <= 0 {
+			return fmt.Errorf("invalid %s %q: it must be a number of cpus greater than 0", name, limit)
+		}
+	}
+	return nil
+}
+
+// ValidateAdditionalMounts makes sure each of the additional_mounts
+// has a target and that its host directory exists.
+func (app *DdevApp) ValidateAdditionalMounts() error {
+	if len(app.AdditionalMounts) > 0 && globalconfig.DdevGlobalConfig.NoBindMounts {
+		return fmt.Errorf("additional_mounts can't be used with no_bind_mounts")
+	}
+	for _, m := range app.AdditionalMounts {
+		if m.Source == "" || !path.IsAbs(m.Target) {
+			return fmt.Errorf("invalid additional_mounts entry %v: source must be set and target must be an absolute path in the container", m)
+		}
+		source := app.GetAdditionalMountSource(m)
+		if !fileutil.FileExists(source) {
+			return fmt.Errorf("the host path %s in additional_mounts does not exist", source)
+		}
+	}
+	return nil
+}
+
+// EffectiveComposeYAML returns the complete docker-compose configuration
+// ddev uses for the project, with the generated base file, any
+// .ddev/docker-compose.*.yaml files and docker-compose.override.yaml merged.
+func (app *DdevApp) EffectiveComposeYAML() (string, error) {
+	app.DockerEnv()
+	err := app.WriteDockerComposeYAML()
+	if err != nil {
+		return "", err
+	}
+	out, err := fileutil.ReadFileIntoString(app.DockerComposeFullRenderedYAMLPath())
+	if err != nil {
+		return "", fmt.Errorf("unable to read rendered file %s: %v", app.DockerComposeFullRenderedYAMLPath(), err)
+	}
+	return out, nil
+}
+
+// ConfigHashLabel is the container label that records the ConfigHash()
+// of the configuration a container was created with.
+const ConfigHashLabel = "com.ddev.config-hash"
+
+// ConfigHash returns a hash of the current project configuration.
+// It is embedded in the generated docker-compose file as a label on each
+// container, so it can be used to find out whether existing containers
+// were created with a different configuration.
+func (app *DdevApp) ConfigHash() (string, error) {
+	cfgbytes, err := yaml.Marshal(app)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(cfgbytes)), nil
+}
+
+// ComposeTemplateVersion is the version of app_compose_template.yaml.
+// It must be incremented whenever a change to the template makes
+// containers created with the previous template incompatible.
+const ComposeTemplateVersion = "1"
+
+// ComposeTemplateVersionLabel is the container label that records the
+// ComposeTemplateVersion a container was created with.
+const ComposeTemplateVersionLabel = "com.ddev.template-version"
+
+// ContainersAreStale returns true if any of the project's existing containers
+// was created with a configuration different from the current one, or if
+// the web container was created from a different version of the compose
+// template, usually by an older ddev.
+func (app *DdevApp) ContainersAreStale() (bool, error) {
+	configHash, err := app.ConfigHash()
+	if err != nil {
+		return false, err
+	}
+	containers, err := dockerutil.GetAppContainers(app.Name)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range containers {
+		if h, ok := c.Labels[ConfigHashLabel]; ok && h != configHash {
+			return true, nil
+		}
+		// Custom services don't carry the template version, but the web
+		// container always comes from the template.
+		if c.Labels["com.docker.compose.service"] == "web" && c.Labels[ComposeTemplateVersionLabel] != ComposeTemplateVersion {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // CheckCustomConfig warns the user if any custom configuration files are in use.
 func (app *DdevApp) CheckCustomConfig() {
 
@@ -666,6 +1326,7 @@ type composeYAMLVars struct {
 	ComposeVersion            string
 	DisableSettingsManagement bool
 	MountType                 string
+	MountConsistency          string
 	WebMount                  string
 	WebBuildContext           string
 	DBBuildContext            string
@@ -678,6 +1339,9 @@ type composeYAMLVars struct {
 	OmitSSHAgent              bool
 	BindAllInterfaces         bool
 	MariaDBVolumeName         string
+	DBReplicaEnabled          bool
+	DBLongQueryTime           float64
+	DBReplicaVolumeName       string
 	MutagenEnabled            bool
 	MutagenVolumeName         string
 	NFSMountEnabled           bool
@@ -703,6 +1367,39 @@ type composeYAMLVars struct {
 	ContainerUploadDir        string
 	HostUploadDir             string
 	GitDirMount               bool
+	HostComposerCacheDir      string
+	SolrEnabled               bool
+	SolrImage                 string
+	SolrVolumeName            string
+	RedisEnabled              bool
+	RedisImage                string
+	RedisPort                 string
+	AdditionalMounts          []AdditionalMount
+	ConfigHash                string
+	ComposeTemplateVersion    string
+	WebMemoryLimit            string
+	WebCPULimit               string
+	DBMemoryLimit             string
+	DBCPULimit                string
+	NetworkName               string
+	HealthcheckInterval       string
+	HealthcheckRetries        int
+	HealthcheckTimeout        string
+	RestartPolicy             string
+	WebEntrypointScript       string
+	WebCommand                string
+	ExtraHosts                []string
+	DNS                       []string
+	DNSSearch                 []string
+	ExposeDBPort              bool
+	DocrootReadOnly           bool
+	HostDocroot               string
+	ContainerDocroot          string
+	DocrootWritableMounts     []AdditionalMount
+	WebEnvFile                string
+	LogDriver                 string
+	LogMaxSize                string
+	LogMaxFile                string
 }
 
 // RenderComposeYAML renders the contents of .ddev/.ddev-docker-compose*.
@@ -726,12 +1423,23 @@ func (app *DdevApp) RenderComposeYAML() (string, error) {
 		}
 	}
 
+	secrets, err := app.GetSecrets()
+	if err != nil {
+		return "", err
+	}
+	webEnvironment = withSecrets(webEnvironment, secrets)
+
 	uid, gid, username := util.GetContainerUIDGid()
 	_, err = app.GetProvider("")
 	if err != nil {
 		return "", err
 	}
 
+	configHash, err := app.ConfigHash()
+	if err != nil {
+		return "", err
+	}
+
 	templateVars := composeYAMLVars{
 		Name:                      app.Name,
 		Plugin:                    "ddev",
@@ -757,6 +1465,7 @@ func (app *DdevApp) RenderComposeYAML() (string, error) {
 		IsWindowsFS:           runtime.GOOS == "windows",
 		NoProjectMount:        app.NoProjectMount,
 		MountType:             "bind",
+		MountConsistency:      app.GetMountConsistency(),
 		WebMount:              "../",
 		Hostnames:             app.GetHostnames(),
 		Timezone:              app.Timezone,
@@ -775,17 +1484,44 @@ func (app *DdevApp) RenderComposeYAML() (string, error) {
 		DBAWorkingDir:         app.GetWorkingDir("dba", ""),
 		WebEnvironment:        webEnvironment,
 		MariaDBVolumeName:     app.GetMariaDBVolumeName(),
+		DBReplicaEnabled:      app.DBReplicaEnabled,
+		DBLongQueryTime:       app.DBLongQueryTime,
+		DBReplicaVolumeName:   app.GetDBReplicaVolumeName(),
 		NFSMountVolumeName:    app.GetNFSMountVolumeName(),
 		NoBindMounts:          globalconfig.DdevGlobalConfig.NoBindMounts,
 		Docroot:               app.GetDocroot(),
 		HostUploadDir:         app.GetHostUploadDirFullPath(),
 		ContainerUploadDir:    app.GetContainerUploadDirFullPath(),
 		GitDirMount:           false,
+		SolrEnabled:           app.SolrEnabled,
+		SolrImage:             version.GetSolrImage(),
+		SolrVolumeName:        app.GetSolrVolumeName(),
+		RedisEnabled:          app.RedisEnabled,
+		RedisImage:            version.GetRedisImage(),
+		RedisPort:             GetPort(RedisService),
+		ConfigHash:            configHash,
+		ExtraHosts:            app.getExtraHosts(hostDockerInternalIP),
+		DNS:                   app.DNS,
+		DNSSearch:             app.DNSSearch,
+		ExposeDBPort:          app.IsDBPortExposed(),
 	}
 	// We don't want to bind-mount git dir if it doesn't exist
 	if fileutil.IsDirectory(filepath.Join(app.AppRoot, ".git")) {
 		templateVars.GitDirMount = true
 	}
+	for _, m := range app.AdditionalMounts {
+		templateVars.AdditionalMounts = append(templateVars.AdditionalMounts, AdditionalMount{
+			Source: dockerutil.MassageWindowsHostMountpoint(app.GetAdditionalMountSource(m)),
+			Target: m.Target,
+		})
+	}
+	// The host composer cache is only mounted if it exists and bind mounts are possible.
+	if app.ComposerCacheMountEnabled && !globalconfig.DdevGlobalConfig.NoBindMounts {
+		hostComposerCacheDir := GetHostComposerCacheDir()
+		if hostComposerCacheDir != "" && fileutil.IsDirectory(hostComposerCacheDir) {
+			templateVars.HostComposerCacheDir = dockerutil.MassageWindowsHostMountpoint(hostComposerCacheDir)
+		}
+	}
 	// And we don't want to bind-mount upload dir if it doesn't exist.
 	// templateVars.UploadDir is relative path rooted in approot.
 	if app.GetHostUploadDirFullPath() == "" || !fileutil.FileExists(app.GetHostUploadDirFullPath()) {
@@ -812,6 +1548,19 @@ func (app *DdevApp) RenderComposeYAML() (string, error) {
 		templateVars.MutagenVolumeName = GetMutagenVolumeName(app)
 	}
 
+	if app.DBReplicaEnabled {
+		err = app.WriteDBReplicationConfig()
+		if err != nil {
+			return "", err
+		}
+	}
+	if app.DBLongQueryTime > 0 {
+		err = app.WriteDBSlowQueryConfig()
+		if err != nil {
+			return "", err
+		}
+	}
+
 	// Add web and db extra dockerfile info
 	// If there is a user-provided Dockerfile, use that as the base and then add
 	// our extra stuff like usernames, etc.
@@ -827,23 +1576,59 @@ func (app *DdevApp) RenderComposeYAML() (string, error) {
 		return "", err
 	}
 
-	err = WriteBuildDockerfile(app.GetConfigPath(".webimageBuild/Dockerfile"), app.GetConfigPath("web-build/Dockerfile"), app.WebImageExtraPackages, app.ComposerVersion)
+	err = WriteBuildDockerfile(app.GetConfigPath(".webimageBuild/Dockerfile"), app.GetConfigPath("web-build/Dockerfile"), app.WebImageExtraPackages, app.ComposerVersion, app.NodeJSVersion, app.xdebugIniCommands()+app.webserverWorkersCommands())
 	if err != nil {
 		return "", err
 	}
 
-	err = WriteBuildDockerfile(app.GetConfigPath(".dbimageBuild/Dockerfile"), app.GetConfigPath("db-build/Dockerfile"), app.DBImageExtraPackages, "")
+	err = WriteBuildDockerfile(app.GetConfigPath(".dbimageBuild/Dockerfile"), app.GetConfigPath("db-build/Dockerfile"), app.DBImageExtraPackages, "", "", "")
 
 	if err != nil {
 		return "", err
 	}
 
 	// SSH agent just needs extra to add the official related user, nothing else
-	err = WriteBuildDockerfile(filepath.Join(globalconfig.GetGlobalDdevDir(), ".sshimageBuild/Dockerfile"), "", nil, "")
+	err = WriteBuildDockerfile(filepath.Join(globalconfig.GetGlobalDdevDir(), ".sshimageBuild/Dockerfile"), "", nil, "", "", "")
 	if err != nil {
 		return "", err
 	}
 
+	templateVars.ComposeTemplateVersion = ComposeTemplateVersion
+	templateVars.WebMemoryLimit = app.WebMemoryLimit
+	templateVars.WebCPULimit = app.WebCPULimit
+	templateVars.DBMemoryLimit = app.DBMemoryLimit
+	templateVars.DBCPULimit = app.DBCPULimit
+	templateVars.NetworkName = app.NetworkName()
+	templateVars.HealthcheckInterval, templateVars.HealthcheckRetries, templateVars.HealthcheckTimeout = app.GetHealthcheckSettings()
+	templateVars.RestartPolicy = app.GetRestartPolicy()
+	templateVars.LogDriver, templateVars.LogMaxSize, templateVars.LogMaxFile = app.GetLogSettings()
+	if fileutil.FileExists(app.GetConfigPath(WebEntrypointScript)) {
+		templateVars.WebEntrypointScript = path.Join("/mnt/ddev_config", WebEntrypointScript)
+	}
+	if app.WebCommand != "" {
+		webCommand := app.WebCommand
+		if templateVars.WebEntrypointScript != "" {
+			webCommand = "bash " + templateVars.WebEntrypointScript + " && " + webCommand
+		}
+		// Quote it for the yaml and keep docker-compose from
+		// interpolating variables the shell should expand
+		quoted, err := json.Marshal(webCommand)
+		if err != nil {
+			return "", err
+		}
+		templateVars.WebCommand = strings.ReplaceAll(string(quoted), "$", "$$")
+	}
+	templateVars.WebEnvFile = app.GetWebEnvFile()
+	if app.DocrootReadOnly {
+		templateVars.DocrootReadOnly = true
+		templateVars.HostDocroot = dockerutil.MassageWindowsHostMountpoint(filepath.Join(app.AppRoot, app.Docroot))
+		templateVars.ContainerDocroot = path.Join("/var/www/html", app.Docroot)
+		templateVars.DocrootWritableMounts, err = app.docrootWritableMounts()
+		if err != nil {
+			return "", err
+		}
+	}
+
 	templateVars.DockerIP, err = dockerutil.GetDockerIP()
 	if err != nil {
 		return "", err
@@ -864,7 +1649,8 @@ func (app *DdevApp) RenderComposeYAML() (string, error) {
 // WriteBuildDockerfile writes a Dockerfile to be used in the
 // docker-compose 'build'
 // It may include the contents of .ddev/<container>-build
-func WriteBuildDockerfile(fullpath string, userDockerfile string, extraPackages []string, composerVersion string) error {
+// extraCommands are added at the end of the web image's Dockerfile
+func WriteBuildDockerfile(fullpath string, userDockerfile string, extraPackages []string, composerVersion string, nodeJSVersion string, extraCommands string) error {
 	// Start with user-built dockerfile if there is one.
 	err := os.MkdirAll(filepath.Dir(fullpath), 0755)
 	if err != nil {
@@ -917,6 +1703,18 @@ RUN apt-get update && DEBIAN_FRONTEND=noninteractive apt-get install -y -o Dpkg:
 		contents = contents + fmt.Sprintf(`
 RUN export XDEBUG_MODE=off && ( composer self-update %s || composer self-update %s || true )
 `, composerSelfUpdateArg, composerSelfUpdateArg)
+
+		// If nodeJSVersion is set, replace the bundled nodejs with that
+		// major version from nodesource
+		if nodeJSVersion != "" {
+			contents = contents + fmt.Sprintf(`
+RUN curl -sSL --fail https://deb.nodesource.com/setup_%s.x | bash - && apt-get remove -y nodejs && DEBIAN_FRONTEND=noninteractive apt-get install -y -o Dpkg::Options::="--force-confold" --no-install-recommends --no-install-suggests nodejs && npm install --global gulp-cli yarn
+`, nodeJSVersion)
+		}
+
+		if extraCommands != "" {
+			contents = contents + "\n" + extraCommands
+		}
 	}
 	return WriteImageDockerfile(fullpath, []byte(contents))
 }
@@ -1063,6 +1861,14 @@ func (app *DdevApp) AppTypePrompt() error {
 	return nil
 }
 
+// ddevGitIgnores are the files ddev generates in the .ddev directory,
+// which are listed in .ddev/.gitignore.
+var ddevGitIgnores = []string{"**/*.example", ".dbimageBuild", ".dbimageExtra", ".dbreplica", ".dbslowlog", ".ddev-docker-*.yaml", ".*downloads", ".global_commands", ".homeadditions", ".sshimageBuild", ".start-timings.json", ".webimageBuild", ".webimageExtra", "apache/apache-site.conf", "commands/.gitattributes", "commands/db/mysql", "commands/host/launch", "commands/web/xdebug", "commands/web/live", "config.*.y*ml", "db_snapshots", "import-db", "import.yaml", "mutagen", "nginx_full/nginx-site.conf", "secrets.yaml", "sequelpro.spf", "xhprof", "**/README.*"}
+
+// legacyDdevGitIgnores are entries older ddev versions listed in
+// .ddev/.gitignore, which aren't kept as user lines when it's rewritten.
+var legacyDdevGitIgnores = []string{"*-build/Dockerfile.example", ".bgsync*", ".ddev-docker-compose-base.yaml", ".ddev-docker-compose-full.yaml", ".dbimageExtra/*", ".importdb*", ".webimageExtra/*", "commands/*/*.example", "commands/*/README.txt", "homeadditions/*.example", "homeadditions/.*.example", "mutagen/mutagen.yml", "nginx-site.conf", "providers/*.example", "providers/README.txt", "xhprof/xhprof_prepend.php"}
+
 // PrepDdevDirectory creates a .ddev directory in the current working directory
 func PrepDdevDirectory(dir string) error {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
@@ -1077,7 +1883,7 @@ func PrepDdevDirectory(dir string) error {
 		}
 	}
 
-	err := CreateGitIgnore(dir, "**/*.example", ".dbimageBuild", ".dbimageExtra", ".ddev-docker-*.yaml", ".*downloads", ".global_commands", ".homeadditions", ".sshimageBuild", ".webimageBuild", ".webimageExtra", "apache/apache-site.conf", "commands/.gitattributes", "commands/db/mysql", "commands/host/launch", "commands/web/xdebug", "commands/web/live", "config.*.y*ml", "db_snapshots", "import-db", "import.yaml", "mutagen", "nginx_full/nginx-site.conf", "sequelpro.spf", "xhprof", "**/README.*")
+	err := CreateGitIgnore(dir, ddevGitIgnores...)
 	if err != nil {
 		return fmt.Errorf("failed to create gitignore in %s: %v", dir, err)
 	}
@@ -2,6 +2,7 @@ package ddevapp_test
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"github.com/drud/ddev/pkg/exec"
 	"github.com/drud/ddev/pkg/nodeps"
@@ -11,17 +12,20 @@ import (
 	"regexp"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	. "github.com/drud/ddev/pkg/ddevapp"
+	"github.com/drud/ddev/pkg/dockerutil"
 	"github.com/drud/ddev/pkg/fileutil"
 	"github.com/drud/ddev/pkg/testcommon"
 	"github.com/drud/ddev/pkg/util"
 	"github.com/drud/ddev/pkg/version"
 	"github.com/google/uuid"
 	asrt "github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 // TestNewConfig tests functionality around creating a new config, writing it to disk, and reading the resulting config.
@@ -142,6 +146,48 @@ func TestPrepDirectory(t *testing.T) {
 	assert.NoError(err)
 }
 
+// TestGitIgnoreKeepsUserLines tests that WriteConfig writes .ddev/.gitignore
+// with the generated files, keeping lines the user added without
+// duplicating anything, and that Init leaves it alone.
+func TestGitIgnoreKeepsUserLines(t *testing.T) {
+	assert := asrt.New(t)
+	testDir := testcommon.CreateTmpDir(t.Name())
+	defer testcommon.CleanupDir(testDir)
+	defer testcommon.Chdir(testDir)()
+
+	app, err := NewApp(testDir, true)
+	require.NoError(t, err)
+	app.Name = strings.ToLower(t.Name())
+	err = app.WriteConfig()
+	require.NoError(t, err)
+
+	gitIgnore := filepath.Join(filepath.Dir(app.ConfigPath), ".gitignore")
+	require.FileExists(t, gitIgnore)
+	require.NoError(t, os.Remove(gitIgnore))
+	err = app.Init(testDir)
+	require.NoError(t, err)
+	assert.NoFileExists(gitIgnore)
+
+	// A .gitignore from an older ddev version, without the user marker
+	oldContent := DdevFileSignature + ": Automatically generated ddev .gitignore.\n/.gitignore\n/.bgsync*\n/.ddev-docker-compose-full.yaml\n/import-db\n/my-notes.txt\n"
+	require.NoError(t, os.WriteFile(gitIgnore, []byte(oldContent), 0644))
+
+	for i := 0; i < 2; i++ {
+		err = app.WriteConfig()
+		require.NoError(t, err)
+	}
+
+	content, err := fileutil.ReadFileIntoString(gitIgnore)
+	require.NoError(t, err)
+	for _, line := range []string{"/.gitignore", "/.ddev-docker-*.yaml", "/import-db", "/xhprof", "/my-notes.txt"} {
+		assert.Equal(1, strings.Count(content, line+"\n"), "expected one %s in %s", line, content)
+	}
+	for _, line := range []string{"/.bgsync*", "/.ddev-docker-compose-full.yaml"} {
+		assert.NotContains(content, line)
+	}
+	assert.True(strings.HasPrefix(content, DdevFileSignature))
+}
+
 // TestHostName tests that the TestSite.Hostname() field returns the hostname as expected.
 func TestHostName(t *testing.T) {
 	assert := asrt.New(t)
@@ -979,6 +1025,399 @@ func TestComposerVersionConfig(t *testing.T) {
 	runTime()
 }
 
+// TestNodeJSVersionConfig tests that composer_version and nodejs_version
+// take effect in the web container.
+func TestNodeJSVersionConfig(t *testing.T) {
+	assert := asrt.New(t)
+	app := &DdevApp{}
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", t.Name(), site.Name))
+	defer runTime()
+
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		app.ComposerVersion = ""
+		app.NodeJSVersion = ""
+		err = app.WriteConfig()
+		assert.NoError(err)
+		err = app.Stop(true, false)
+		assert.NoError(err)
+	})
+
+	app.NodeJSVersion = "11"
+	err = app.ValidateConfig()
+	assert.Error(err)
+
+	app.ComposerVersion = "2"
+	app.NodeJSVersion = "14"
+	err = app.WriteConfig()
+	require.NoError(t, err)
+	err = app.Restart()
+	require.NoError(t, err)
+
+	stdout, _, err := app.Exec(&ExecOpts{
+		Cmd: "composer --version | awk '{print $3;}'",
+	})
+	require.NoError(t, err)
+	assert.True(strings.HasPrefix(strings.TrimSpace(stdout), "2."), "composer version is %s", stdout)
+
+	stdout, _, err = app.Exec(&ExecOpts{
+		Cmd: "node --version",
+	})
+	require.NoError(t, err)
+	assert.True(strings.HasPrefix(strings.TrimSpace(stdout), "v14."), "node version is %s", stdout)
+}
+
+// TestComposerCacheMount tests that composer_cache_mount_enabled mounts the
+// host composer cache, and only if it exists.
+func TestComposerCacheMount(t *testing.T) {
+	if nodeps.NoBindMountsDefault {
+		t.Skip("Skipping because composer_cache_mount_enabled does nothing with NoBindMounts")
+	}
+	assert := asrt.New(t)
+	app := &DdevApp{}
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", t.Name(), site.Name))
+	defer runTime()
+
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+
+	cacheDir := testcommon.CreateTmpDir(t.Name())
+	t.Cleanup(func() {
+		_ = os.RemoveAll(cacheDir)
+	})
+	t.Setenv("COMPOSER_CACHE_DIR", cacheDir)
+	assert.Equal(cacheDir, GetHostComposerCacheDir())
+
+	app.ComposerCacheMountEnabled = true
+	expectedMount := fmt.Sprintf("%s:/mnt/ddev-global-cache/composer:rw", dockerutil.MassageWindowsHostMountpoint(cacheDir))
+	contents, err := app.RenderComposeYAML()
+	require.NoError(t, err)
+	assert.Contains(contents, expectedMount)
+
+	// A nonexistent host cache dir is not mounted.
+	t.Setenv("COMPOSER_CACHE_DIR", filepath.Join(cacheDir, "nonexistent"))
+	contents, err = app.RenderComposeYAML()
+	require.NoError(t, err)
+	assert.NotContains(contents, "/mnt/ddev-global-cache/composer")
+
+	// And nothing is mounted when the option is off.
+	t.Setenv("COMPOSER_CACHE_DIR", cacheDir)
+	app.ComposerCacheMountEnabled = false
+	contents, err = app.RenderComposeYAML()
+	require.NoError(t, err)
+	assert.NotContains(contents, "/mnt/ddev-global-cache/composer")
+}
+
+// TestHealthcheckSettings tests that the healthcheck timing is validated
+// and rendered into the compose file.
+func TestHealthcheckSettings(t *testing.T) {
+	assert := asrt.New(t)
+	app := &DdevApp{}
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", t.Name(), site.Name))
+	defer runTime()
+
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+
+	contents, err := app.RenderComposeYAML()
+	require.NoError(t, err)
+	assert.Contains(contents, "interval: 1s\n      retries: 120\n")
+
+	app.HealthcheckInterval = "soon"
+	err = app.ValidateConfig()
+	assert.Error(err)
+
+	app.HealthcheckInterval = "5s"
+	app.HealthcheckRetries = 60
+	app.HealthcheckTimeout = "300s"
+	err = app.ValidateConfig()
+	require.NoError(t, err)
+	contents, err = app.RenderComposeYAML()
+	require.NoError(t, err)
+	assert.NotContains(contents, "interval: 1s\n")
+	// The db and web healthchecks
+	assert.Equal(2, strings.Count(contents, "interval: 5s\n      retries: 60\n      start_period: 120s\n      timeout: 300s\n"))
+}
+
+// TestResourceLimits tests that memory and cpu limits are validated and
+// applied to the containers.
+func TestResourceLimits(t *testing.T) {
+	assert := asrt.New(t)
+	app := &DdevApp{}
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", t.Name(), site.Name))
+	defer runTime()
+
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		app.WebMemoryLimit = ""
+		app.WebCPULimit = ""
+		app.DBMemoryLimit = ""
+		err = app.WriteConfig()
+		assert.NoError(err)
+		err = app.Restart()
+		assert.NoError(err)
+	})
+
+	for _, limits := range [][]string{{"lots", ""}, {"", "0"}, {"", "many"}} {
+		app.WebMemoryLimit = limits[0]
+		app.WebCPULimit = limits[1]
+		err = app.ValidateConfig()
+		assert.Error(err, "limits %v should be invalid", limits)
+	}
+
+	app.WebMemoryLimit = "1g"
+	app.WebCPULimit = "1"
+	app.DBMemoryLimit = "768m"
+	err = app.ValidateConfig()
+	require.NoError(t, err)
+	err = app.WriteConfig()
+	require.NoError(t, err)
+	err = app.Restart()
+	require.NoError(t, err)
+
+	web, err := dockerutil.InspectContainer(fmt.Sprintf("ddev-%s-web", app.Name))
+	require.NoError(t, err)
+	assert.Equal(int64(1024*1024*1024), web.HostConfig.Memory)
+	assert.Equal(int64(1000000000), web.HostConfig.NanoCPUs)
+
+	db, err := dockerutil.InspectContainer(fmt.Sprintf("ddev-%s-db", app.Name))
+	require.NoError(t, err)
+	assert.Equal(int64(768*1024*1024), db.HostConfig.Memory)
+	assert.Equal(int64(0), db.HostConfig.NanoCPUs)
+}
+
+// TestRestartPolicy tests that restart_policy is validated and applied to
+// the containers.
+func TestRestartPolicy(t *testing.T) {
+	assert := asrt.New(t)
+	app := &DdevApp{}
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", t.Name(), site.Name))
+	defer runTime()
+
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		app.RestartPolicy = ""
+		err = app.WriteConfig()
+		assert.NoError(err)
+		err = app.Restart()
+		assert.NoError(err)
+	})
+
+	app.RestartPolicy = "sometimes"
+	err = app.ValidateConfig()
+	assert.Error(err)
+
+	app.RestartPolicy = "unless-stopped"
+	err = app.ValidateConfig()
+	require.NoError(t, err)
+	err = app.WriteConfig()
+	require.NoError(t, err)
+	err = app.Restart()
+	require.NoError(t, err)
+
+	for _, service := range []string{"web", "db"} {
+		container, err := dockerutil.InspectContainer(fmt.Sprintf("ddev-%s-%s", app.Name, service))
+		require.NoError(t, err)
+		assert.Equal("unless-stopped", container.HostConfig.RestartPolicy.Name, "wrong restart policy for %s", service)
+	}
+}
+
+// TestDNSSettings tests that dns and dns_search are validated and applied
+// to the web and db containers.
+func TestDNSSettings(t *testing.T) {
+	assert := asrt.New(t)
+	app := &DdevApp{}
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", t.Name(), site.Name))
+	defer runTime()
+
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		app.DNS = nil
+		app.DNSSearch = nil
+		err = app.WriteConfig()
+		assert.NoError(err)
+		err = app.Restart()
+		assert.NoError(err)
+	})
+
+	app.DNS = []string{"dns.example.com"}
+	assert.Error(app.ValidateConfig())
+	app.DNS = nil
+	app.DNSSearch = []string{"not a domain"}
+	assert.Error(app.ValidateConfig())
+
+	app.DNS = []string{"10.0.0.2", "10.0.0.3"}
+	app.DNSSearch = []string{"corp.example.com"}
+	err = app.ValidateConfig()
+	require.NoError(t, err)
+	err = app.WriteConfig()
+	require.NoError(t, err)
+	err = app.Restart()
+	require.NoError(t, err)
+
+	for _, service := range []string{"web", "db"} {
+		container, err := dockerutil.InspectContainer(fmt.Sprintf("ddev-%s-%s", app.Name, service))
+		require.NoError(t, err)
+		assert.Equal([]string{"10.0.0.2", "10.0.0.3"}, container.HostConfig.DNS, "wrong dns for %s", service)
+		assert.Equal([]string{"corp.example.com"}, container.HostConfig.DNSSearch, "wrong dns_search for %s", service)
+	}
+}
+
+// TestExposeDBPort tests that expose_db_port: false keeps the db port from
+// being published to the host
+func TestExposeDBPort(t *testing.T) {
+	assert := asrt.New(t)
+	app := &DdevApp{}
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", t.Name(), site.Name))
+	defer runTime()
+
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		app.ExposeDBPort = nil
+		app.HostDBPort = ""
+		err = app.WriteConfig()
+		assert.NoError(err)
+		err = app.Restart()
+		assert.NoError(err)
+	})
+
+	// By default the port is published
+	assert.True(app.IsDBPortExposed())
+	err = app.Restart()
+	require.NoError(t, err)
+	creds, err := app.DBCredentials()
+	require.NoError(t, err)
+	assert.Greater(creds.Port, 0)
+
+	exposeDBPort := false
+	app.ExposeDBPort = &exposeDBPort
+	app.HostDBPort = "33060"
+	assert.Error(app.ValidateConfig())
+	app.HostDBPort = ""
+	require.NoError(t, app.ValidateConfig())
+	err = app.WriteConfig()
+	require.NoError(t, err)
+	err = app.Restart()
+	require.NoError(t, err)
+
+	container, err := dockerutil.InspectContainer(fmt.Sprintf("ddev-%s-db", app.Name))
+	require.NoError(t, err)
+	for port, bindings := range container.NetworkSettings.Ports {
+		assert.Empty(bindings, "db port %s is published", port)
+	}
+	publishedPort, err := app.GetPublishedPort("db")
+	require.NoError(t, err)
+	assert.Equal(0, publishedPort)
+
+	_, err = app.DBCredentials()
+	assert.ErrorIs(err, ErrDBPortNotExposed)
+
+	// The database is still there for the web container
+	_, _, err = app.Exec(&ExecOpts{
+		Service: "web",
+		Cmd:     "mysql -e 'SELECT 1;'",
+	})
+	assert.NoError(err)
+}
+
+// TestLogSettings tests that log_driver, log_max_size and log_max_file are
+// validated and rendered into the logging of the containers.
+func TestLogSettings(t *testing.T) {
+	assert := asrt.New(t)
+	app := &DdevApp{}
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", t.Name(), site.Name))
+	defer runTime()
+
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+
+	loggingOf := func(service string) map[interface{}]interface{} {
+		contents, err := app.RenderComposeYAML()
+		require.NoError(t, err)
+		compose := map[string]interface{}{}
+		err = yaml.Unmarshal([]byte(contents), &compose)
+		require.NoError(t, err)
+		services := compose["services"].(map[interface{}]interface{})
+		return services[service].(map[interface{}]interface{})["logging"].(map[interface{}]interface{})
+	}
+
+	// By default the logs are rotated
+	logging := loggingOf("web")
+	assert.Equal(DefaultLogDriver, logging["driver"])
+	assert.Equal(map[interface{}]interface{}{"max-size": DefaultLogMaxSize, "max-file": strconv.Itoa(DefaultLogMaxFile)}, logging["options"])
+
+	for _, invalid := range []DdevApp{{LogMaxSize: "lots"}, {LogMaxSize: "10mb"}, {LogMaxFile: -1}, {LogDriver: "syslog", LogMaxSize: "10m"}} {
+		app.LogDriver, app.LogMaxSize, app.LogMaxFile = invalid.LogDriver, invalid.LogMaxSize, invalid.LogMaxFile
+		assert.Error(app.ValidateConfig(), "log settings %s/%s/%d should be invalid", invalid.LogDriver, invalid.LogMaxSize, invalid.LogMaxFile)
+	}
+
+	app.LogDriver, app.LogMaxSize, app.LogMaxFile = "", "20m", 2
+	require.NoError(t, app.ValidateConfig())
+	for _, service := range []string{"web", "db"} {
+		logging = loggingOf(service)
+		assert.Equal("json-file", logging["driver"])
+		assert.Equal(map[interface{}]interface{}{"max-size": "20m", "max-file": "2"}, logging["options"], "wrong logging options for %s", service)
+	}
+
+	// Drivers that don't rotate get no options
+	app.LogDriver, app.LogMaxSize, app.LogMaxFile = "none", "", 0
+	require.NoError(t, app.ValidateConfig())
+	logging = loggingOf("web")
+	assert.Equal("none", logging["driver"])
+	assert.NotContains(logging, "options")
+	app.LogDriver = ""
+}
+
 // TestCustomBuildDockerfiles tests to make sure that custom web-build and db-build
 // Dockerfiles work properly
 func TestCustomBuildDockerfiles(t *testing.T) {
@@ -1145,3 +1584,144 @@ func TestPkgConfigMariaDBVersion(t *testing.T) {
 	}
 
 }
+
+// TestWriteDockerComposeYAMLUnwritable checks that failing to write the
+// generated docker-compose file gives an error naming the file
+func TestWriteDockerComposeYAMLUnwritable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("Skipping because directory permissions can't be relied on for Windows or root")
+	}
+	assert := asrt.New(t)
+	testDir := testcommon.CreateTmpDir(t.Name())
+	app := &DdevApp{AppRoot: testDir, Name: t.Name()}
+
+	err := os.MkdirAll(app.AppConfDir(), 0555)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Chmod(app.AppConfDir(), 0755)
+		err = os.RemoveAll(testDir)
+		assert.NoError(err)
+	})
+
+	err = app.WriteDockerComposeYAML()
+	require.Error(t, err)
+	assert.True(errors.Is(err, os.ErrPermission), "err=%v", err)
+	assert.Contains(err.Error(), app.DockerComposeYAMLPath())
+	assert.Contains(err.Error(), "writable")
+}
+
+// TestImageRegistry checks that image_registry is used for the project's
+// images in the generated docker-compose file.
+func TestImageRegistry(t *testing.T) {
+	assert := asrt.New(t)
+	app := &DdevApp{}
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", t.Name(), site.Name))
+	defer runTime()
+
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		app.ImageRegistry = ""
+		app.DockerEnv()
+		err = app.WriteDockerComposeYAML()
+		assert.NoError(err)
+	})
+
+	for _, invalid := range []string{"https://myregistry.local", "myregistry", "my registry.local"} {
+		app.ImageRegistry = invalid
+		assert.Error(app.ValidateConfig(), "image_registry %s should be invalid", invalid)
+	}
+
+	app.ImageRegistry = "myregistry.local/ddev/"
+	require.NoError(t, app.ValidateConfig())
+	assert.Equal("myregistry.local/ddev/"+app.WebImage, app.GetWebImageFromRegistry())
+	assert.Equal("myregistry.local/ddev/"+app.GetDBImage(), app.GetDBImageFromRegistry())
+	assert.Equal("myregistry.local/ddev/"+app.DBAImage, app.GetDBAImageFromRegistry())
+
+	// Images that name their own registry are used as they are
+	origWebImage := app.WebImage
+	app.WebImage = "otherregistry.local:5000/web:latest"
+	assert.Equal(app.WebImage, app.GetWebImageFromRegistry())
+	app.WebImage = origWebImage
+
+	app.DockerEnv()
+	err = app.WriteDockerComposeYAML()
+	require.NoError(t, err)
+	contents, err := os.ReadFile(app.DockerComposeFullRenderedYAMLPath())
+	require.NoError(t, err)
+	compose := map[string]interface{}{}
+	err = yaml.Unmarshal(contents, &compose)
+	require.NoError(t, err)
+	services := compose["services"].(map[interface{}]interface{})
+	for service, image := range map[string]string{"web": app.GetWebImageFromRegistry(), "db": app.GetDBImageFromRegistry()} {
+		s := services[service].(map[interface{}]interface{})
+		assert.Equal(image+"-"+app.Name+"-built", s["image"])
+		args := s["build"].(map[interface{}]interface{})["args"].(map[interface{}]interface{})
+		assert.Equal(image, args["BASE_IMAGE"])
+	}
+	assert.Equal(app.GetDBAImageFromRegistry(), services["dba"].(map[interface{}]interface{})["image"])
+}
+
+// TestMountType tests that mount_type sets how the project is mounted in
+// the generated compose file
+func TestMountType(t *testing.T) {
+	if runtime.GOOS != "darwin" {
+		t.Skip("Skipping because mount_type is only about Docker Desktop for Mac")
+	}
+	assert := asrt.New(t)
+	app := &DdevApp{}
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", t.Name(), site.Name))
+	defer runTime()
+
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+	app.MutagenEnabled = false
+	app.NFSMountEnabled = false
+	if app.IsMutagenEnabled() || app.NFSMountEnabledGlobal {
+		t.Skip("Skipping because mutagen or NFS is enabled globally")
+	}
+
+	app.MountType = "nocache"
+	assert.Error(app.ValidateConfig())
+	app.MountType = MountTypeBind
+	app.NFSMountEnabled = true
+	assert.Error(app.ValidateConfig())
+	app.NFSMountEnabled = false
+	app.NFSMountEnabledGlobal = true
+	assert.Error(app.ValidateConfig())
+	app.NFSMountEnabledGlobal = false
+	app.MutagenEnabledGlobal = true
+	assert.Error(app.ValidateConfig())
+	app.MutagenEnabledGlobal = false
+	app.MutagenEnabled = true
+	assert.Error(app.ValidateConfig())
+	app.MountType = MountTypeSync
+	assert.NoError(app.ValidateConfig())
+	app.MutagenEnabled = false
+
+	for mountType, consistency := range map[string]string{"": "cached", MountTypeBind: "consistent", MountTypeCached: "cached", MountTypeDelegated: "delegated"} {
+		app.MountType = mountType
+		require.NoError(t, app.ValidateConfig())
+		contents, err := app.RenderComposeYAML()
+		require.NoError(t, err)
+		assert.Contains(contents, "consistency: "+consistency+"\n", "wrong project mount for mount_type %q", mountType)
+		assert.NotContains(contents, "project_mutagen")
+	}
+
+	app.MountType = MountTypeSync
+	assert.True(app.IsMutagenEnabled())
+	contents, err := app.RenderComposeYAML()
+	require.NoError(t, err)
+	assert.Contains(contents, "source: project_mutagen")
+	assert.NotContains(contents, "consistency:")
+}
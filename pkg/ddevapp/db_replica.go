@@ -0,0 +1,89 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/drud/ddev/pkg/util"
+)
+
+// DBReplicaService is the docker-compose service name of the optional
+// read-replica database container.
+const DBReplicaService = "db-replica"
+
+// dbReplicationCnf is mounted into both the primary and the replica db
+// containers when db_replica_enabled is set.
+// server_id can't be set here, because ddev-dbserver starts mysqld with
+// --server-id=0, so it is set at runtime by StartDBReplication()
+const dbReplicationCnf = `# ` + DdevFileSignature + `
+[mysqld]
+log-bin=mysql-bin
+relay-log=relay-bin
+binlog-format=ROW
+`
+
+// GetDBReplicaVolumeName returns the docker volume name of the replica database volume
+func (app *DdevApp) GetDBReplicaVolumeName() string {
+	return app.Name + "-mariadb-replica"
+}
+
+// WriteDBReplicationConfig writes the mysqld configuration needed for
+// replication into .ddev/.dbreplica
+func (app *DdevApp) WriteDBReplicationConfig() error {
+	cnfPath := app.GetConfigPath(filepath.Join(".dbreplica", "replication.cnf"))
+	err := os.MkdirAll(filepath.Dir(cnfPath), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cnfPath, []byte(dbReplicationCnf), 0644)
+}
+
+// StartDBReplication makes the db-replica service replicate from the primary db.
+// If the replica has already been configured (on an earlier start) replication
+// is just restarted from where it left off.
+func (app *DdevApp) StartDBReplication() error {
+	_, _, err := app.Exec(&ExecOpts{
+		Service: "db",
+		Cmd:     `mysql -uroot -proot -e "SET GLOBAL server_id=1;"`,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set server_id on primary db: %v", err)
+	}
+
+	replicaStatus, _, err := app.Exec(&ExecOpts{
+		Service: DBReplicaService,
+		Cmd:     `mysql -uroot -proot -N -B -e "SHOW SLAVE STATUS;"`,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get replica status: %v", err)
+	}
+
+	replicaSQL := "SET GLOBAL server_id=2; SET GLOBAL read_only=1; "
+	if strings.TrimSpace(replicaStatus) == "" {
+		stdout, _, err := app.Exec(&ExecOpts{
+			Service: "db",
+			Cmd:     `mysql -uroot -proot -N -B -e "SHOW MASTER STATUS;"`,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get primary db binary log position: %v", err)
+		}
+		fields := strings.Fields(stdout)
+		if len(fields) < 2 {
+			return fmt.Errorf("binary logging does not seem to be enabled on the primary db (SHOW MASTER STATUS='%s'), you may need to 'ddev restart'", stdout)
+		}
+		replicaSQL = replicaSQL + fmt.Sprintf("CHANGE MASTER TO MASTER_HOST='db', MASTER_USER='root', MASTER_PASSWORD='root', MASTER_LOG_FILE='%s', MASTER_LOG_POS=%s; ", fields[0], fields[1])
+	}
+	replicaSQL = replicaSQL + "START SLAVE;"
+
+	_, stderr, err := app.Exec(&ExecOpts{
+		Service: DBReplicaService,
+		Cmd:     fmt.Sprintf(`mysql -uroot -proot -e "%s"`, replicaSQL),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start replication on %s: %v, stderr=%s", DBReplicaService, err, stderr)
+	}
+	util.Success("Database replication from db to %s is running", DBReplicaService)
+	return nil
+}
@@ -0,0 +1,64 @@
+package ddevapp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drud/ddev/pkg/nodeps"
+)
+
+// Database servers ExportDBCompatible can make dumps for
+const (
+	ExportCompatMySQL57 = "mysql:5.7"
+	ExportCompatMySQL80 = "mysql:8.0"
+	ExportCompatMariaDB = "mariadb"
+)
+
+// ValidExportCompat are the values ExportDBCompatible takes for compat
+var ValidExportCompat = []string{ExportCompatMySQL57, ExportCompatMySQL80, ExportCompatMariaDB}
+
+// exportCompatDumpFlags returns the mysqldump flags and the sed script
+// that make the dump of the project's database importable into the
+// server compat names. The sed script leaves INSERT lines alone, so only
+// the schema is changed, not the data.
+func (app *DdevApp) exportCompatDumpFlags(compat string) ([]string, string, error) {
+	if compat == "" {
+		return nil, "", nil
+	}
+	if !nodeps.ArrayContainsString(ValidExportCompat, compat) {
+		return nil, "", fmt.Errorf("invalid export compatibility %q: it must be one of %s", compat, strings.Join(ValidExportCompat, ", "))
+	}
+
+	// Tablespaces are specific to the server's data directory
+	flags := []string{"--no-tablespaces", "--default-character-set=utf8mb4"}
+	var substitutions []string
+
+	// The mysqldump of MySQL 8.0 adds column statistics that older
+	// servers and MariaDB don't know about
+	if app.MySQLVersion == nodeps.MySQL80 && compat != ExportCompatMySQL80 {
+		flags = append(flags, "--column-statistics=0")
+	}
+
+	switch compat {
+	case ExportCompatMySQL57, ExportCompatMySQL80:
+		// MariaDB's NO PAD collations, like utf8mb4_unicode_nopad_ci,
+		// don't exist in MySQL
+		substitutions = append(substitutions, `s/_nopad_/_/g`)
+	}
+	switch compat {
+	case ExportCompatMySQL57, ExportCompatMariaDB:
+		// MySQL 8.0's default utf8mb4_0900_ai_ci and the other 0900
+		// collations don't exist in MySQL 5.7 or MariaDB
+		substitutions = append(substitutions, `s/utf8mb4_0900_bin/utf8mb4_bin/g`, `s/utf8mb4_([a-z]+_)*0900_a[is]_c[is]/utf8mb4_unicode_ci/g`)
+	}
+	if compat == ExportCompatMySQL57 {
+		// MySQL 5.7 only knows utf8mb3 as utf8
+		substitutions = append(substitutions, `s/utf8mb3/utf8/g`)
+	}
+
+	script := ""
+	if len(substitutions) > 0 {
+		script = "/^INSERT INTO/!{" + strings.Join(substitutions, ";") + "}"
+	}
+	return flags, script, nil
+}
@@ -0,0 +1,55 @@
+package ddevapp
+
+import (
+	"fmt"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+	"github.com/drud/ddev/pkg/nodeps"
+)
+
+// DBConnection is what a client on the host needs to connect to a
+// project's database.
+type DBConnection struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	DBName   string
+}
+
+// IsDBPortExposed reports whether the db container's port is published to
+// the host. It is unless expose_db_port is false.
+func (app *DdevApp) IsDBPortExposed() bool {
+	return app.ExposeDBPort == nil || *app.ExposeDBPort
+}
+
+// DBCredentials returns how a client on the host connects to the running
+// project's database. If the db port isn't published to the host the
+// error wraps ErrDBPortNotExposed, and the database can only be reached
+// from the project's containers, as db:3306.
+func (app *DdevApp) DBCredentials() (*DBConnection, error) {
+	if nodeps.ArrayContainsString(app.GetOmittedContainers(), "db") {
+		return nil, fmt.Errorf("project %s has no db container", app.Name)
+	}
+	if !app.IsDBPortExposed() {
+		return nil, fmt.Errorf("%w because project %s has expose_db_port: false; use host db and port %s from the project's containers", ErrDBPortNotExposed, app.Name, GetPort("db"))
+	}
+	port, err := app.GetPublishedPort("db")
+	if err != nil {
+		return nil, err
+	}
+	if port <= 0 {
+		return nil, fmt.Errorf("%w: the db container of project %s has no published port", ErrDBPortNotExposed, app.Name)
+	}
+	host, err := dockerutil.GetDockerIP()
+	if err != nil {
+		return nil, err
+	}
+	return &DBConnection{
+		Host:     host,
+		Port:     port,
+		Username: "db",
+		Password: "db",
+		DBName:   "db",
+	}, nil
+}
@@ -0,0 +1,159 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+	"github.com/drud/ddev/pkg/nodeps"
+	"github.com/drud/ddev/pkg/util"
+)
+
+// MigrateDB moves the project's database from one db server version to
+// another, like "mariadb_10.3" to "mysql_8.0". Versions may also be given
+// as "mariadb:10.4" or just "10.4". The data directory of one server
+// version often can't be used by another, so every database but the
+// server's own is exported from a db container of fromVersion, the
+// database volume is recreated and the dumps are imported into a db
+// container of toVersion. A snapshot is
+// made first, in case anything goes wrong. The project's config.yaml is
+// updated to toVersion and the project is left running.
+func (app *DdevApp) MigrateDB(fromVersion, toVersion string) error {
+	if nodeps.ArrayContainsString(app.GetOmittedContainers(), "db") {
+		return fmt.Errorf("the db container is omitted for project %s, so there is no database to migrate", app.Name)
+	}
+	from, err := parseDBVersion(fromVersion)
+	if err != nil {
+		return err
+	}
+	to, err := parseDBVersion(toVersion)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("the database is already on %s", from)
+	}
+
+	app.setDBVersion(from)
+	err = app.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start project with %s: %v", from, err)
+	}
+	snapshotName, err := app.Snapshot(app.Name + "_before_migration_" + time.Now().Format("20060102150405"))
+	if err != nil {
+		return fmt.Errorf("failed to snapshot the %s database before the migration: %v", from, err)
+	}
+
+	databases, err := app.userDatabases()
+	if err != nil {
+		return fmt.Errorf("failed to list the %s databases: %v", from, err)
+	}
+	dumpDir, err := os.MkdirTemp("", "ddev-migrate-db")
+	if err != nil {
+		return err
+	}
+	dumpFiles := map[string]string{}
+	for _, db := range databases {
+		dumpFiles[db] = filepath.Join(dumpDir, db+".sql.gz")
+		err = app.ExportDB(dumpFiles[db], true, db)
+		if err != nil {
+			_ = os.RemoveAll(dumpDir)
+			return fmt.Errorf("failed to export the %s database %s: %v", from, db, err)
+		}
+	}
+
+	err = app.Stop(false, false)
+	if err != nil {
+		return err
+	}
+	vols := []string{app.GetMariaDBVolumeName()}
+	if app.DBReplicaEnabled {
+		vols = append(vols, app.GetDBReplicaVolumeName())
+	}
+	for _, volName := range vols {
+		err = dockerutil.RemoveVolume(volName)
+		if err != nil {
+			return fmt.Errorf("failed to remove volume %s, the databases were exported to %s: %v", volName, dumpDir, err)
+		}
+	}
+
+	app.setDBVersion(to)
+	err = app.WriteConfig()
+	if err != nil {
+		return err
+	}
+	err = app.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start project with %s, the databases were exported to %s and snapshot %s was made with %s: %v", to, dumpDir, snapshotName, from, err)
+	}
+	for _, db := range databases {
+		err = app.ForceImportDB(dumpFiles[db], "", false, false, db)
+		if err != nil {
+			return fmt.Errorf("failed to import the database %s into %s, the databases were exported to %s and snapshot %s was made with %s: %v", db, to, dumpDir, snapshotName, from, err)
+		}
+	}
+	_ = os.RemoveAll(dumpDir)
+
+	util.Success("Migrated the database of project %s from %s to %s. Snapshot %s has the %s database.", app.Name, from, to, snapshotName, from)
+	return nil
+}
+
+// userDatabases returns the databases on the project's db server other than
+// the server's own, like mysql and information_schema.
+func (app *DdevApp) userDatabases() ([]string, error) {
+	stdout, stderr, err := app.Exec(&ExecOpts{
+		Service: "db",
+		Cmd:     `mysql -uroot -proot -N -B -e "SHOW DATABASES;"`,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, stderr)
+	}
+	systemDatabases := []string{"information_schema", "mysql", "performance_schema", "sys"}
+	databases := []string{}
+	for _, db := range strings.Split(stdout, "\n") {
+		db = strings.TrimSpace(db)
+		if db != "" && !nodeps.ArrayContainsString(systemDatabases, db) {
+			databases = append(databases, db)
+		}
+	}
+	return databases, nil
+}
+
+// parseDBVersion returns the db server type and version in v, like
+// "mariadb:10.4", "mysql_8.0" or "10.4", in the form
+// db_mariadb_version.txt records it, like "mariadb_10.4".
+func parseDBVersion(v string) (string, error) {
+	full := strings.Replace(v, ":", "_", 1)
+	if !strings.Contains(full, "_") {
+		full = fullDBFromVersion(full)
+	}
+	parts := strings.SplitN(full, "_", 2)
+	if len(parts) == 2 {
+		switch {
+		case parts[0] == nodeps.MariaDB && nodeps.IsValidMariaDBVersion(parts[1]):
+			return full, nil
+		case parts[0] == nodeps.MySQL && nodeps.IsValidMySQLVersion(parts[1]):
+			return full, nil
+		}
+	}
+	return "", fmt.Errorf("invalid database version %q: it must be like mariadb:%s or mysql:8.0", v, nodeps.MariaDBDefaultVersion)
+}
+
+// setDBVersion configures the project for the db server version full,
+// like "mariadb_10.4", which must have been checked by parseDBVersion.
+func (app *DdevApp) setDBVersion(full string) {
+	parts := strings.SplitN(full, "_", 2)
+	app.MariaDBVersion, app.MySQLVersion = "", ""
+	if parts[0] == nodeps.MySQL {
+		app.MySQLVersion = parts[1]
+	} else {
+		app.MariaDBVersion = parts[1]
+	}
+	// GetDBImage() only derives the image from the version if DBImage,
+	// which Start sets to the image it used, is empty
+	app.DBImage = ""
+	app.DBImage = app.GetDBImage()
+}
@@ -0,0 +1,71 @@
+package ddevapp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+	"github.com/drud/ddev/pkg/nodeps"
+	"github.com/drud/ddev/pkg/util"
+	"github.com/drud/ddev/pkg/version"
+)
+
+// ImportDBFromVolume replaces the project's database with the database
+// data directory in the docker volume volumeName, for example one seeded
+// in CI from a copy of another project's <project>-mariadb volume.
+// The data has to come from the same db server type and version as
+// the project uses. A running project is restarted with the new data.
+func (app *DdevApp) ImportDBFromVolume(volumeName string) error {
+	if nodeps.ArrayContainsString(app.GetOmittedContainers(), "db") {
+		return fmt.Errorf("the db container is omitted for project %s, so there is no database to import into", app.Name)
+	}
+	if volumeName == app.GetMariaDBVolumeName() {
+		return fmt.Errorf("volume %s is the project's own database volume", volumeName)
+	}
+	if !dockerutil.VolumeExists(volumeName) {
+		return fmt.Errorf("docker volume %s does not exist", volumeName)
+	}
+
+	_, out, err := dockerutil.RunSimpleContainer(version.GetWebImage(), "", []string{"sh", "-c", "cat /mnt/seed/db_mariadb_version.txt 2>/dev/null || true"}, []string{}, []string{}, []string{volumeName + ":/mnt/seed:ro"}, "", true, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read volume %s: %v, output=%s", volumeName, err, out)
+	}
+	seedDBVersion := strings.Trim(out, "\r\n\t ")
+	if seedDBVersion == "" {
+		return fmt.Errorf("volume %s does not contain a ddev database data directory", volumeName)
+	}
+	if !strings.Contains(seedDBVersion, "_") {
+		seedDBVersion = fullDBFromVersion(seedDBVersion)
+	}
+	if seedDBVersion != app.fullDBVersion() {
+		return fmt.Errorf("volume %s holds a '%s' database and is not compatible with the configured ddev DB server version (%s)", volumeName, seedDBVersion, app.fullDBVersion())
+	}
+
+	status := app.SiteStatus()
+	running := status == SiteRunning || status == SitePaused
+	if running {
+		dbContainer, err := GetContainer(app, "db")
+		if err != nil || dbContainer == nil {
+			return fmt.Errorf("no container found for db; err=%v", err)
+		}
+		err = dockerutil.RemoveContainer(dbContainer.ID, 20)
+		if err != nil {
+			return fmt.Errorf("failed to remove db container: %v", err)
+		}
+	}
+
+	util.Success("Importing database from volume %s...", volumeName)
+	_, out, err = dockerutil.RunSimpleContainer(version.GetWebImage(), "", []string{"sh", "-c", "find /var/lib/mysql -mindepth 1 -delete && cp -a /mnt/seed/. /var/lib/mysql/"}, []string{}, []string{}, []string{volumeName + ":/mnt/seed:ro", app.GetMariaDBVolumeName() + ":/var/lib/mysql"}, "0", true, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to copy volume %s into %s: %v, output=%s", volumeName, app.GetMariaDBVolumeName(), err, out)
+	}
+
+	if running {
+		err = app.Start()
+		if err != nil {
+			return fmt.Errorf("failed to start project after ImportDBFromVolume: %v", err)
+		}
+	}
+	util.Success("Imported database from volume %s", volumeName)
+	return nil
+}
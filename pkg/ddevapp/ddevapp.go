@@ -1,18 +1,22 @@
 package ddevapp
 
 import (
+	"bufio"
 	"bytes"
 	"embed"
 	"fmt"
 	"gopkg.in/yaml.v2"
+	"io"
 	"io/fs"
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/drud/ddev/pkg/globalconfig"
 	"github.com/drud/ddev/pkg/nodeps"
@@ -62,6 +66,13 @@ const SitePaused = "paused"
 // If this string is found, we assume we can replace/update the file.
 const DdevFileSignature = "#ddev-generated"
 
+// AdditionalMount is a host directory (absolute or relative to the
+// project root) bind-mounted into the web container.
+type AdditionalMount struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
+
 // DdevApp is the struct that represents a ddev app, mostly its config
 // from config.yaml.
 type DdevApp struct {
@@ -73,9 +84,12 @@ type DdevApp struct {
 	WebImage              string                `yaml:"webimage,omitempty"`
 	DBImage               string                `yaml:"dbimage,omitempty"`
 	DBAImage              string                `yaml:"dbaimage,omitempty"`
+	ImageRegistry         string                `yaml:"image_registry,omitempty"`
 	RouterHTTPPort        string                `yaml:"router_http_port"`
 	RouterHTTPSPort       string                `yaml:"router_https_port"`
 	XdebugEnabled         bool                  `yaml:"xdebug_enabled"`
+	XdebugPort            int                   `yaml:"xdebug_port,omitempty"`
+	XdebugIDEKey          string                `yaml:"xdebug_ide_key,omitempty"`
 	NoProjectMount        bool                  `yaml:"no_project_mount,omitempty"`
 	AdditionalHostnames   []string              `yaml:"additional_hostnames"`
 	AdditionalFQDNs       []string              `yaml:"additional_fqdns"`
@@ -87,6 +101,8 @@ type DdevApp struct {
 	MutagenEnabledGlobal  bool                  `yaml:"-"`
 	FailOnHookFail        bool                  `yaml:"fail_on_hook_fail,omitempty"`
 	BindAllInterfaces     bool                  `yaml:"bind_all_interfaces,omitempty"`
+	DBReplicaEnabled      bool                  `yaml:"db_replica_enabled,omitempty"`
+	DBLongQueryTime       float64               `yaml:"db_long_query_time,omitempty"`
 	FailOnHookFailGlobal  bool                  `yaml:"-"`
 	ConfigPath            string                `yaml:"-"`
 	AppRoot               string                `yaml:"-"`
@@ -118,7 +134,50 @@ type DdevApp struct {
 	Timezone                  string                 `yaml:"timezone,omitempty"`
 	ComposerVersion           string                 `yaml:"composer_version"`
 	DisableSettingsManagement bool                   `yaml:"disable_settings_management,omitempty"`
+	DisableHostsManagement    bool                   `yaml:"disable_hosts_management,omitempty"`
+	ComposerCacheMountEnabled bool                   `yaml:"composer_cache_mount_enabled,omitempty"`
+	FixtureDB                 string                 `yaml:"fixture_db,omitempty"`
+	FixtureFiles              string                 `yaml:"fixture_files,omitempty"`
+	SolrEnabled               bool                   `yaml:"solr_enabled,omitempty"`
+	HostSolrPort              string                 `yaml:"host_solr_port,omitempty"`
+	RedisEnabled              bool                   `yaml:"redis_enabled,omitempty"`
+	AdditionalMounts          []AdditionalMount      `yaml:"additional_mounts,omitempty"`
+	BasePath                  string                 `yaml:"base_path,omitempty"`
+	NodeJSVersion             string                 `yaml:"nodejs_version,omitempty"`
+	WebMemoryLimit            string                 `yaml:"web_memory_limit,omitempty"`
+	WebCPULimit               string                 `yaml:"web_cpu_limit,omitempty"`
+	DBMemoryLimit             string                 `yaml:"db_memory_limit,omitempty"`
+	DBCPULimit                string                 `yaml:"db_cpu_limit,omitempty"`
+	DockerNetworkName         string                 `yaml:"network_name,omitempty"`
+	WarmupURLs                []string               `yaml:"warmup_urls,omitempty"`
+	HealthcheckInterval       string                 `yaml:"healthcheck_interval,omitempty"`
+	HealthcheckRetries        int                    `yaml:"healthcheck_retries,omitempty"`
+	HealthcheckTimeout        string                 `yaml:"healthcheck_timeout,omitempty"`
+	Multisites                []Multisite            `yaml:"multisites,omitempty"`
+	RestartPolicy             string                 `yaml:"restart_policy,omitempty"`
+	StopGracePeriod           string                 `yaml:"stop_grace_period,omitempty"`
+	DocrootReadOnly           bool                   `yaml:"docroot_read_only,omitempty"`
+	DocrootWritableDirs       []string               `yaml:"docroot_writable_dirs,omitempty,flow"`
+	LogDriver                 string                 `yaml:"log_driver,omitempty"`
+	LogMaxSize                string                 `yaml:"log_max_size,omitempty"`
+	LogMaxFile                int                    `yaml:"log_max_file,omitempty"`
+	WebCommand                string                 `yaml:"web_command,omitempty"`
+	ExtraHosts                []string               `yaml:"extra_hosts,omitempty,flow"`
+	ImportFilesOwner          string                 `yaml:"import_files_owner,omitempty"`
+	DNS                       []string               `yaml:"dns,omitempty,flow"`
+	DNSSearch                 []string               `yaml:"dns_search,omitempty,flow"`
+	GeneratedComposeDir       string                 `yaml:"generated_compose_dir,omitempty"`
+	ValidateComposeOnStart    bool                   `yaml:"validate_compose,omitempty"`
+	WebEnvFile                string                 `yaml:"web_env_file,omitempty"`
 	WebEnvironment            []string               `yaml:"web_environment"`
+	DirectoryListing          bool                   `yaml:"directory_listing,omitempty"`
+	ImportTimeout             string                 `yaml:"import_timeout,omitempty"`
+	WebserverWorkers          int                    `yaml:"webserver_workers,omitempty"`
+	FastCGIBuffers            string                 `yaml:"fastcgi_buffers,omitempty"`
+	FastCGIBufferSize         string                 `yaml:"fastcgi_buffer_size,omitempty"`
+	ExistingContainers        string                 `yaml:"existing_containers,omitempty"`
+	ExposeDBPort              *bool                  `yaml:"expose_db_port,omitempty"`
+	MountType                 string                 `yaml:"mount_type,omitempty"`
 	ComposeYaml               map[string]interface{} `yaml:"-"`
 }
 
@@ -144,6 +203,7 @@ func (app *DdevApp) Init(basePath string) error {
 	}
 
 	*app = *newApp
+
 	web, err := app.FindContainerByType("web")
 
 	if err != nil {
@@ -167,6 +227,44 @@ func (app *DdevApp) Init(basePath string) error {
 	return nil
 }
 
+// InitWithType is like Init, but the project type is appType instead of
+// the configured or detected one. This is useful for new or empty projects
+// where DetectCMS can't tell what the project will be. If appType is ""
+// a project without a config.yaml gets the detected type. A project without
+// a config.yaml also gets the discovered docroot, like "pub" for magento2.
+func (app *DdevApp) InitWithType(basePath string, appType string) error {
+	if appType != "" && !IsValidAppType(appType) {
+		return fmt.Errorf("'%s' is not a valid project type. Allowed project types are: %s", appType, strings.Join(GetValidAppTypes(), ", "))
+	}
+
+	err := app.Init(basePath)
+	if err != nil {
+		return err
+	}
+
+	newConfig := !fileutil.FileExists(app.ConfigPath)
+	if newConfig && app.Docroot == "" {
+		app.Docroot = DiscoverDefaultDocroot(app)
+	}
+	if appType == "" {
+		if !newConfig {
+			return nil
+		}
+		appType = app.DetectAppType()
+	}
+	app.Type = appType
+
+	// As with ddev config, the type's overrides only apply to a new config.
+	if newConfig {
+		err = app.ConfigFileOverrideAction()
+		if err != nil {
+			return err
+		}
+	}
+	app.SetApptypeSettingsPaths()
+	return nil
+}
+
 // FindContainerByType will find a container for this site denoted by the containerType if it is available.
 func (app *DdevApp) FindContainerByType(containerType string) (*docker.APIContainers, error) {
 	labels := map[string]string{
@@ -199,6 +297,17 @@ func (app *DdevApp) Describe(short bool) (map[string]interface{}, error) {
 	appDesc["primary_url"] = app.GetPrimaryURL()
 	appDesc["type"] = app.GetType()
 	appDesc["mutagen_enabled"] = app.IsMutagenEnabled()
+	if len(app.Multisites) > 0 {
+		multisites := []map[string]string{}
+		for _, site := range app.GetMultisites() {
+			multisites = append(multisites, map[string]string{
+				"name":     site.Name,
+				"url":      app.MultisiteURL(site),
+				"database": site.Database,
+			})
+		}
+		appDesc["multisites"] = multisites
+	}
 	if app.IsMutagenEnabled() {
 		appDesc["mutagen_status"], _, _, err = app.MutagenStatus()
 		if err != nil {
@@ -214,6 +323,9 @@ func (app *DdevApp) Describe(short bool) (map[string]interface{}, error) {
 	appDesc["hostnames"] = app.GetHostnames()
 	appDesc["nfs_mount_enabled"] = (app.NFSMountEnabled || app.NFSMountEnabledGlobal) && !(app.IsMutagenEnabled())
 	appDesc["fail_on_hook_fail"] = app.FailOnHookFail || app.FailOnHookFailGlobal
+	appDesc["db_replica_enabled"] = app.DBReplicaEnabled
+	appDesc["composer_cache_mount_enabled"] = app.ComposerCacheMountEnabled
+	appDesc["network_name"] = app.NetworkName()
 	httpURLs, httpsURLs, allURLs := app.GetAllURLs()
 	appDesc["httpURLs"] = httpURLs
 	appDesc["httpsURLs"] = httpsURLs
@@ -254,6 +366,9 @@ func (app *DdevApp) Describe(short bool) (map[string]interface{}, error) {
 					dbinfo["mariadb_version"] = nodeps.MariaDBDefaultVersion
 				}
 			}
+			if app.DBReplicaEnabled {
+				dbinfo["replica_host"] = DBReplicaService
+			}
 			appDesc["dbinfo"] = dbinfo
 
 			if !nodeps.ArrayContainsString(app.GetOmittedContainers(), "dba") {
@@ -262,6 +377,13 @@ func (app *DdevApp) Describe(short bool) (map[string]interface{}, error) {
 			}
 		}
 
+		if app.SolrEnabled {
+			appDesc["solr_url"] = app.SolrURL()
+		}
+		if app.RedisEnabled {
+			appDesc["redis_host"] = RedisService + ":" + GetPort(RedisService)
+		}
+
 		appDesc["mailhog_https_url"] = "https://" + app.GetHostname() + ":" + app.MailhogHTTPSPort
 		appDesc["mailhog_url"] = "http://" + app.GetHostname() + ":" + app.MailhogPort
 	}
@@ -276,9 +398,9 @@ func (app *DdevApp) Describe(short bool) (map[string]interface{}, error) {
 	appDesc["router_http_port"] = app.RouterHTTPPort
 	appDesc["router_https_port"] = app.RouterHTTPSPort
 	appDesc["xdebug_enabled"] = app.XdebugEnabled
-	appDesc["webimg"] = app.WebImage
-	appDesc["dbimg"] = app.GetDBImage()
-	appDesc["dbaimg"] = app.DBAImage
+	appDesc["webimg"] = app.GetWebImageFromRegistry()
+	appDesc["dbimg"] = app.GetDBImageFromRegistry()
+	appDesc["dbaimg"] = app.GetDBAImageFromRegistry()
 	appDesc["services"] = map[string]map[string]string{}
 
 	containers, err := dockerutil.GetAppContainers(app.Name)
@@ -384,6 +506,17 @@ func (app *DdevApp) GetAppRoot() string {
 	return app.AppRoot
 }
 
+// HostWorkingDir returns the host directory of the project's code, as passed to Init
+func (app *DdevApp) HostWorkingDir() string {
+	return app.AppRoot
+}
+
+// ContainerWorkingDir returns the directory in the web container
+// where the project's code (HostWorkingDir) is mounted
+func (app *DdevApp) ContainerWorkingDir() string {
+	return "/var/www/html"
+}
+
 // AppConfDir returns the full path to the app's .ddev configuration directory
 func (app *DdevApp) AppConfDir() string {
 	return filepath.Join(app.AppRoot, ".ddev")
@@ -418,13 +551,73 @@ func (app *DdevApp) GetWebserverType() string {
 }
 
 // ImportDB takes a source sql dump and imports it to an active site's database container.
+// If targetDB was last imported from the same dump, it isn't imported again
+// and ErrDBImportUnchanged is returned; ForceImportDB() imports it anyway.
+// Imports with noDrop always happen, since adding the same data twice isn't
+// the same as adding it once.
 func (app *DdevApp) ImportDB(imPath string, extPath string, progress bool, noDrop bool, targetDB string) error {
+	return app.importDB(imPath, extPath, progress, noDrop, targetDB, false, false, nil, nil)
+}
+
+// ForceImportDB is like ImportDB, but it imports the dump even if targetDB
+// was last imported from it.
+func (app *DdevApp) ForceImportDB(imPath string, extPath string, progress bool, noDrop bool, targetDB string) error {
+	return app.importDB(imPath, extPath, progress, noDrop, targetDB, true, false, nil, nil)
+}
+
+// ImportDBTransactional imports a dump that only changes data, like one made
+// with mysqldump --no-create-info, in a single transaction on top of the
+// existing database. If the import fails it is rolled back, so the database
+// is left as it was. Statements like CREATE TABLE can't be rolled back, so a
+// dump containing them is imported like ImportDB(..., noDrop=true) does,
+// with a warning.
+func (app *DdevApp) ImportDBTransactional(imPath string, extPath string, progress bool, targetDB string) error {
+	return app.importDB(imPath, extPath, progress, true, targetDB, true, true, nil, nil)
+}
+
+// importDB does the work of ImportDB(), ForceImportDB(),
+// ImportDBTransactional(), ImportDBWithProgress() and ImportDBEvents(). Only
+// imports that replace the database record their dump, and unless force is
+// true they're skipped if it's the one recorded. If report isn't nil,
+// the progress of importing a dump file is reported to it, and if onPhase
+// isn't nil it's called with each ImportPhase* as the import reaches it.
+func (app *DdevApp) importDB(imPath string, extPath string, progress bool, noDrop bool, targetDB string, force bool, transactional bool, report ImportReporter, onPhase func(phase string)) error {
+	startPhase := func(phase string) {
+		if onPhase != nil {
+			onPhase(phase)
+		}
+	}
+	startPhase(ImportPhaseDownload)
+	if imPath != "" {
+		if err := validateImportSource(imPath, "db"); err != nil {
+			return err
+		}
+	}
 	app.DockerEnv()
-	dockerutil.CheckAvailableSpace()
+	if imPath != "" {
+		if err := app.checkImportSpace(imPath); err != nil {
+			return err
+		}
+	} else {
+		dockerutil.CheckAvailableSpace()
+	}
 	if targetDB == "" {
 		targetDB = "db"
 	}
+
+	importHash := ""
+	if imPath != "" && !noDrop && !transactional {
+		var err error
+		importHash, err = importSourceHash(imPath, extPath)
+		if err != nil {
+			return err
+		}
+		if !force && app.lastImportHash(targetDB) == importHash {
+			return fmt.Errorf("%w: %s", ErrDBImportUnchanged, imPath)
+		}
+	}
 	var extPathPrompt bool
+	var sourceSize, extractedSize int64
 	dbPath, err := os.MkdirTemp(filepath.Dir(app.ConfigPath), ".importdb")
 
 	defer func() {
@@ -464,6 +657,7 @@ func (app *DdevApp) ImportDB(imPath string, extPath string, progress bool, noDro
 			}
 		}
 
+		startPhase(ImportPhaseExtract)
 		switch {
 		case strings.HasSuffix(importPath, "sql.gz") || strings.HasSuffix(importPath, "mysql.gz"):
 			err = archive.Ungzip(importPath, dbPath)
@@ -502,8 +696,54 @@ func (app *DdevApp) ImportDB(imPath string, extPath string, progress bool, noDro
 		if len(matches) < 1 {
 			return fmt.Errorf("no .sql or .mysql files found to import")
 		}
+
+		if report != nil {
+			info, err := os.Stat(importPath)
+			if err != nil {
+				return err
+			}
+			sourceSize = info.Size()
+			for _, match := range matches {
+				info, err := os.Stat(match)
+				if err != nil {
+					return err
+				}
+				extractedSize += info.Size()
+			}
+		}
+
+		hasStatement := false
+		var charsets []string
+		for _, match := range matches {
+			info, err := scanSQLFile(match)
+			if err != nil {
+				return err
+			}
+			hasStatement = hasStatement || info.hasStatement
+			if transactional && info.hasDDL {
+				util.Warning("%s changes the database structure, which can't be rolled back, so it is not imported in a transaction", filepath.Base(match))
+				transactional = false
+			}
+			if len(info.usedDatabases) > 0 {
+				util.Warning("%s selects other databases (%s), those statements are removed so everything is imported into the '%s' database", filepath.Base(match), strings.Join(info.usedDatabases, ", "), targetDB)
+			}
+			for _, charset := range info.charsets {
+				if !nodeps.ArrayContainsString(charsets, charset) {
+					charsets = append(charsets, charset)
+				}
+			}
+		}
+		if !hasStatement {
+			return fmt.Errorf("%w: %s", ErrEmptyDump, imPath)
+		}
+
+		err = app.checkImportCharsets(charsets, targetDB, !noDrop)
+		if err != nil {
+			return err
+		}
 	}
 
+	startPhase(ImportPhaseLoad)
 	// default insideContainerImportPath is the one mounted from .ddev directory
 	insideContainerImportPath := path.Join("/mnt/ddev_config/", filepath.Base(dbPath))
 	// But if we don't have bind mounts, we have to copy dump into the container
@@ -538,21 +778,53 @@ func (app *DdevApp) ImportDB(imPath string, extPath string, progress bool, noDro
 	// throw off imports. This is a scary manipulation, as it must not match actual content
 	// as has actually happened with https://www.ddevhq.org/ddev-local/ddev-local-database-management/
 	// and in https://github.com/drud/ddev/issues/2787
-	// The backtick after USE is inserted via fmt.Sprintf argument because it seems there's
-	// no way to escape a backtick in a string literal.
-	inContainerCommand := fmt.Sprintf(`mysql -uroot -proot -e "%s" && pv %s/*.*sql | perl -p -e 's/^(CREATE DATABASE \/\*|USE %s)[^;]*;//' | mysql %s`, preImportSQL, insideContainerImportPath, "`", targetDB)
+	inContainerCommand := fmt.Sprintf(`mysql -uroot -proot -e "%s" && %s %s/*.*sql | perl -p -e '%s' | mysql %s`, preImportSQL, importPVCommand(report), insideContainerImportPath, stripDatabaseStatementsPerl, targetDB)
 
 	// Handle the case where we are reading from stdin
 	if imPath == "" && extPath == "" {
-		inContainerCommand = fmt.Sprintf(`mysql -uroot -proot -e "%s" && perl -p -e 's/^(CREATE DATABASE \/\*|USE %s)[^;]*;//' | mysql %s`, preImportSQL, "`", targetDB)
+		if transactional {
+			util.Warning("A database read from stdin can't be checked, so it is not imported in a transaction")
+			transactional = false
+		}
+		inContainerCommand = fmt.Sprintf(`mysql -uroot -proot -e "%s" && perl -p -e '%s' | mysql %s`, preImportSQL, stripDatabaseStatementsPerl, targetDB)
+	}
+
+	// mysql stops at the first error, and the transaction is rolled back
+	// when it disconnects without COMMIT.
+	if transactional {
+		inContainerCommand = fmt.Sprintf(`mysql -uroot -proot -e "%s" && ( echo "SET autocommit=0; START TRANSACTION;" && %s %s/*.*sql | perl -p -e '%s' | perl -p -e '%s' && echo "COMMIT;" ) | mysql %s`, preImportSQL, importPVCommand(report), insideContainerImportPath, stripDatabaseStatementsPerl, stripImplicitCommitsPerl, targetDB)
+	}
+	// timeout kills the whole import, including the mysql client, when
+	// it takes longer than import_timeout
+	importTimeout := app.GetImportTimeout()
+	if importTimeout > 0 {
+		inContainerCommand = fmt.Sprintf("timeout --kill-after=5s %ss bash -c '%s'", strconv.FormatFloat(importTimeout.Seconds(), 'f', -1, 64), strings.ReplaceAll(inContainerCommand, "'", `'\''`))
+	}
+	// The record of the last import goes first, so a failed import leaves none
+	inContainerCommand = fmt.Sprintf("rm -f %s && %s", dbImportMarker(targetDB), inContainerCommand)
+	if importHash != "" {
+		inContainerCommand = fmt.Sprintf("%s && echo %s >%s", inContainerCommand, importHash, dbImportMarker(targetDB))
 	}
-	_, _, err = app.Exec(&ExecOpts{
+	importOpts := &ExecOpts{
 		Service: "db",
 		Cmd:     inContainerCommand,
 		Tty:     progress && isatty.IsTerminal(os.Stdin.Fd()),
-	})
+	}
+	var stderr string
+	importStart := time.Now()
+	if report != nil && imPath != "" {
+		stderr, err = app.execReportingImportProgress(importOpts, sourceSize, extractedSize, report)
+	} else {
+		_, stderr, err = app.Exec(importOpts)
+	}
 
 	if err != nil {
+		if importTimeout > 0 && time.Since(importStart) >= importTimeout {
+			return fmt.Errorf("%w after %s, the import_timeout of project %s", ErrImportTimeout, importTimeout, app.Name)
+		}
+		if mysqlErrors := lastMySQLErrors(stderr, 3); mysqlErrors != "" {
+			return fmt.Errorf("failed to import database: %v: %s", err, mysqlErrors)
+		}
 		return err
 	}
 
@@ -581,6 +853,7 @@ func (app *DdevApp) ImportDB(imPath string, extPath string, progress bool, noDro
 		}
 	}
 
+	startPhase(ImportPhasePostProcess)
 	_, err = app.CreateSettingsFile()
 	if err != nil {
 		util.Warning("A custom settings file exists for your application, so ddev did not generate one.")
@@ -605,20 +878,240 @@ func (app *DdevApp) ImportDB(imPath string, extPath string, progress bool, noDro
 	return nil
 }
 
+// lastMySQLErrors returns up to max of the last "ERROR ..." lines
+// in mysql client output, joined with "; "
+func lastMySQLErrors(out string, max int) string {
+	var errorLines []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "ERROR") {
+			errorLines = append(errorLines, line)
+		}
+	}
+	if len(errorLines) > max {
+		errorLines = errorLines[len(errorLines)-max:]
+	}
+	return strings.Join(errorLines, "; ")
+}
+
+// stripDatabaseStatementsPerl is a perl substitution removing the CREATE
+// DATABASE and USE statements mysqldump writes, so a dump is imported into
+// the target database whatever database it was made from.
+var stripDatabaseStatementsPerl = `s/^(CREATE DATABASE \/\*|USE ` + "`" + `)[^;]*;//`
+
+// useDatabaseRegex matches the USE statements stripDatabaseStatementsPerl
+// removes, capturing the database name
+var useDatabaseRegex = regexp.MustCompile("^USE `([^;]*)`;")
+
+// stripImplicitCommitsPerl is a perl substitution removing the LOCK TABLES
+// and ALTER TABLE ... DISABLE KEYS statements mysqldump puts around data,
+// which would commit a transaction.
+var stripImplicitCommitsPerl = `s/^((UN)?LOCK TABLES[^;]*|\/\*!40000 ALTER TABLE [^;]* (DIS|EN)ABLE KEYS \*\/);//i`
+
+// ddlStatementRegex matches the start of statements that change the
+// database structure, other than the CREATE DATABASE statements ImportDB
+// removes.
+var ddlStatementRegex = regexp.MustCompile(`(?i)^\s*(CREATE\s+(?:DATABASE)?|ALTER|DROP|RENAME|TRUNCATE)\b`)
+
+// declaredCharsetRegex matches the default charsets a dump declares for
+// its databases and tables. Column charsets aren't included, since dumps
+// like Drupal's use ascii for some columns of utf8mb4 tables. Neither is
+// the connection charset of SET NAMES, the server converts from it.
+var declaredCharsetRegex = regexp.MustCompile(`(?i)(?:DEFAULT\s+(?:CHARSET|CHARACTER\s+SET)\s*=?|(?:CHARSET|CHARACTER\s+SET)\s*=|CREATE\s+(?:DATABASE|SCHEMA)\b[^;]*?\b(?:CHARSET|CHARACTER\s+SET))\s*['"]?(\w+)`)
+
+// sqlFileInfo is what ImportDB needs to know about a dump before importing it
+type sqlFileInfo struct {
+	// hasStatement is true if the dump has anything other than whitespace
+	// and comments. MySQL's executable /*! ... */ comments count as statements.
+	hasStatement bool
+	// hasDDL is true if the dump has statements like CREATE TABLE that
+	// can't be part of a transaction
+	hasDDL bool
+	// usedDatabases are the databases the dump selects with USE
+	// statements, which ImportDB removes
+	usedDatabases []string
+	// charsets are the database and table charsets the dump declares,
+	// like utf8mb4 in "DEFAULT CHARSET=utf8mb4". utf8mb3 is returned as
+	// utf8, which it's an alias of.
+	charsets []string
+}
+
+// scanSQLFile reads a dump once to find what ImportDB checks before
+// importing it.
+func scanSQLFile(sqlFile string) (sqlFileInfo, error) {
+	var info sqlFileInfo
+	f, err := os.Open(sqlFile)
+	if err != nil {
+		return info, err
+	}
+	defer util.CheckClose(f)
+
+	scanner := bufio.NewScanner(f)
+	// Dumps can have very long lines (extended inserts)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024*1024)
+	inComment := false
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// The data is most of the dump and can't be anything but a statement
+		if strings.HasPrefix(line, "INSERT ") {
+			info.hasStatement = true
+			continue
+		}
+
+		if !info.hasStatement {
+			info.hasStatement, inComment = sqlLineHasStatement(strings.TrimSpace(line), inComment)
+		}
+
+		if !info.hasDDL {
+			m := ddlStatementRegex.FindStringSubmatch(line)
+			if m != nil && !strings.HasSuffix(strings.ToUpper(m[1]), "DATABASE") {
+				info.hasDDL = true
+			}
+		}
+
+		if strings.HasPrefix(line, "USE `") {
+			if m := useDatabaseRegex.FindStringSubmatch(line); m != nil && !nodeps.ArrayContainsString(info.usedDatabases, m[1]) {
+				info.usedDatabases = append(info.usedDatabases, m[1])
+			}
+		}
+
+		for _, m := range declaredCharsetRegex.FindAllStringSubmatch(line, -1) {
+			charset := normalizeCharset(m[1])
+			if charset != "binary" && !nodeps.ArrayContainsString(info.charsets, charset) {
+				info.charsets = append(info.charsets, charset)
+			}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return info, fmt.Errorf("unable to read %s: %v", sqlFile, err)
+	}
+	return info, nil
+}
+
+// sqlLineHasStatement returns true if a trimmed line of a dump has anything
+// other than whitespace and comments, and whether the line leaves a
+// multi-line comment open.
+func sqlLineHasStatement(line string, inComment bool) (bool, bool) {
+	if inComment {
+		return false, !strings.Contains(line, "*/")
+	}
+	switch {
+	case line == "" || strings.HasPrefix(line, "--") || strings.HasPrefix(line, "#"):
+		return false, false
+	case strings.HasPrefix(line, "/*") && !strings.HasPrefix(line, "/*!"):
+		end := strings.Index(line, "*/")
+		if end < 0 {
+			return false, true
+		}
+		if strings.TrimSpace(line[end+2:]) == "" {
+			return false, false
+		}
+	}
+	return true, false
+}
+
+// normalizeCharset returns the lowercase name of a charset, with utf8mb3
+// as utf8.
+func normalizeCharset(charset string) string {
+	charset = strings.ToLower(charset)
+	if charset == "utf8mb3" {
+		return "utf8"
+	}
+	return charset
+}
+
+// checkImportCharsets warns if the declared charsets of the dumps aren't
+// the one of targetDB, because text can be converted or mangled on the way.
+// If recreated is true targetDB is dropped and created again before the
+// import, so it will have the server's default charset whatever it has now.
+func (app *DdevApp) checkImportCharsets(declared []string, targetDB string, recreated bool) error {
+	if len(declared) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`SELECT COALESCE((SELECT DEFAULT_CHARACTER_SET_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME='%s'), @@character_set_server);`, targetDB)
+	if recreated {
+		query = `SELECT @@character_set_server;`
+	}
+	out, _, err := app.Exec(&ExecOpts{
+		Service: "db",
+		Cmd:     fmt.Sprintf(`mysql -N -e "%s"`, query),
+	})
+	if err != nil {
+		util.Debug("unable to get the charset of database %s: %v", targetDB, err)
+		return nil
+	}
+	dbCharset := normalizeCharset(strings.TrimSpace(out))
+
+	var mismatched []string
+	for _, charset := range declared {
+		if charset != dbCharset {
+			mismatched = append(mismatched, charset)
+		}
+	}
+	if len(mismatched) > 0 {
+		util.Warning("The dump declares charset %s, but the '%s' database uses %s; text may be converted or mangled on import, please check the imported data", strings.Join(mismatched, ", "), targetDB, dbCharset)
+	}
+	return nil
+}
+
+// DBStats returns the total size (data and indexes) in bytes and the
+// number of tables of the default "db" database.
+func (app *DdevApp) DBStats() (sizeBytes int64, tableCount int, err error) {
+	stdout, stderr, err := app.Exec(&ExecOpts{
+		Service: "db",
+		Cmd:     `mysql -uroot -proot -N -B -e "SELECT COALESCE(SUM(data_length + index_length), 0), COUNT(*) FROM information_schema.tables WHERE table_schema='db';"`,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get database stats: %v, stderr=%s", err, stderr)
+	}
+	fields := strings.Fields(stdout)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected output getting database stats: '%s'", stdout)
+	}
+	sizeBytes, err = strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to parse database size '%s': %v", fields[0], err)
+	}
+	tableCount, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to parse table count '%s': %v", fields[1], err)
+	}
+	return sizeBytes, tableCount, nil
+}
+
 // ExportDB exports the db, with optional output to a file, default gzip
 // targetDB is the db name if not default "db"
 func (app *DdevApp) ExportDB(outFile string, gzip bool, targetDB string) error {
+	return app.ExportDBCompatible(outFile, gzip, targetDB, "")
+}
+
+// ExportDBCompatible is like ExportDB, but it makes a dump that can be
+// imported into another database server, one of ValidExportCompat, even
+// if the project uses a different one. The mysqldump flags are adjusted
+// and collations and charsets the other server doesn't know are replaced
+// in the table definitions. compat "" is the same as ExportDB.
+func (app *DdevApp) ExportDBCompatible(outFile string, gzip bool, targetDB string, compat string) error {
 	app.DockerEnv()
 	if targetDB == "" {
 		targetDB = "db"
 	}
+	flags, sedScript, err := app.exportCompatDumpFlags(compat)
+	if err != nil {
+		return err
+	}
+	dumpCmd := strings.Join(append([]string{"mysqldump"}, append(flags, targetDB)...), " ")
+	if sedScript != "" {
+		dumpCmd = dumpCmd + " | sed -E '" + sedScript + "'"
+	}
 	opts := &ExecOpts{
 		Service:   "db",
-		Cmd:       "mysqldump " + targetDB,
+		Cmd:       dumpCmd,
 		NoCapture: true,
 	}
 	if gzip {
-		opts.Cmd = fmt.Sprintf("mysqldump %s | gzip", targetDB)
+		opts.Cmd = opts.Cmd + " | gzip"
 	}
 	if outFile != "" {
 		f, err := os.OpenFile(outFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
@@ -631,13 +1124,16 @@ func (app *DdevApp) ExportDB(outFile string, gzip bool, targetDB string) error {
 		}()
 	}
 
-	_, _, err := app.Exec(opts)
+	_, _, err = app.Exec(opts)
 
 	if err != nil {
 		return err
 	}
 
 	confMsg := "Wrote database dump from " + app.Name + " database '" + targetDB + "'"
+	if compat != "" {
+		confMsg = confMsg + " for " + compat
+	}
 	if outFile != "" {
 		confMsg = confMsg + " to file " + outFile
 	} else {
@@ -710,6 +1206,31 @@ func (app *DdevApp) SiteStatus() string {
 
 // ImportFiles takes a source directory or archive and copies to the uploaded files directory of a given app.
 func (app *DdevApp) ImportFiles(importPath string, extPath string) error {
+	return app.ImportFilesStripComponents(importPath, extPath, 0)
+}
+
+// ImportFilesStripComponents imports files like ImportFiles, but drops the
+// first stripComponents directories of the paths in an archive, like
+// tar --strip-components. An archive with everything in files/ can be
+// imported with 1. The paths are stripped after extracting extPath.
+func (app *DdevApp) ImportFilesStripComponents(importPath string, extPath string, stripComponents int) error {
+	if err := validateImportSource(importPath, "files"); err != nil {
+		return err
+	}
+	if stripComponents < 0 {
+		return fmt.Errorf("invalid number of path components to strip: %d", stripComponents)
+	}
+	if stripComponents > 0 {
+		if !isTar(importPath) && !isZip(importPath) {
+			return fmt.Errorf("path components can only be stripped from an archive, %s isn't one", importPath)
+		}
+		strippedPath, cleanup, err := extractStripped(importPath, extPath, stripComponents)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		importPath, extPath = strippedPath, ""
+	}
 	app.DockerEnv()
 
 	if err := app.ProcessHooks("pre-import-files"); err != nil {
@@ -720,6 +1241,144 @@ func (app *DdevApp) ImportFiles(importPath string, extPath string) error {
 		return err
 	}
 
+	if app.SiteStatus() == SiteRunning {
+		if err := app.FixFilePermissions(); err != nil {
+			return err
+		}
+	}
+
+	//nolint: revive
+	if err := app.ProcessHooks("post-import-files"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ImportFilesToContainer imports a directory or archive like ImportFiles,
+// but extracts it straight into containerPath in the web container instead
+// of the upload directory on the host. It's for files that live only in the
+// container, for example with no_bind_mounts or in a path that isn't
+// mounted. Existing files in containerPath are kept unless the import
+// overwrites them.
+func (app *DdevApp) ImportFilesToContainer(importPath, extPath, containerPath string) error {
+	if err := validateImportSource(importPath, "files"); err != nil {
+		return err
+	}
+	if !path.IsAbs(containerPath) {
+		return fmt.Errorf("the container path %s must be an absolute path", containerPath)
+	}
+	app.DockerEnv()
+
+	if err := app.ProcessHooks("pre-import-files"); err != nil {
+		return err
+	}
+
+	srcPath := importPath
+	if isTar(importPath) || isZip(importPath) {
+		tmpDir, err := os.MkdirTemp("", "ddev-import-files")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if isTar(importPath) {
+			err = archive.Untar(importPath, tmpDir, extPath)
+		} else {
+			err = archive.Unzip(importPath, tmpDir, extPath)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to extract provided archive: %v", err)
+		}
+		srcPath = tmpDir
+	}
+
+	util.Success("Importing files from %s into %s in the web container", importPath, containerPath)
+	if err := dockerutil.CopyIntoContainer(srcPath, GetContainerName(app, "web"), containerPath, ""); err != nil {
+		return fmt.Errorf("failed to copy files into %s in the web container: %v", containerPath, err)
+	}
+	if err := app.fixFileOwnership(containerPath); err != nil {
+		return err
+	}
+
+	//nolint: revive
+	if err := app.ProcessHooks("post-import-files"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ImportAll imports the database dump at dbPath and the files at
+// filesPath at the same time. The two imports use different containers,
+// so they don't get in each other's way. Either path may be empty to skip
+// that import.
+func (app *DdevApp) ImportAll(dbPath string, filesPath string) error {
+	app.DockerEnv()
+
+	var dbErr, filesErr error
+	wg := sync.WaitGroup{}
+	if dbPath != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dbErr = app.ForceImportDB(dbPath, "", false, false, "db")
+		}()
+	}
+	if filesPath != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			filesErr = app.ImportFiles(filesPath, "")
+		}()
+	}
+	wg.Wait()
+
+	switch {
+	case dbErr != nil && filesErr != nil:
+		return fmt.Errorf("failed to import database: %v; failed to import files: %v", dbErr, filesErr)
+	case dbErr != nil:
+		return fmt.Errorf("failed to import database: %v", dbErr)
+	case filesErr != nil:
+		return fmt.Errorf("failed to import files: %v", filesErr)
+	}
+	return nil
+}
+
+// ImportFilesFromSSH uses rsync over ssh to copy remotePath on sshTarget
+// (user@host or a Host alias from the user's ssh config) into the project's
+// upload directory.
+func (app *DdevApp) ImportFilesFromSSH(sshTarget, remotePath string) error {
+	if !util.IsCommandAvailable("rsync") {
+		return fmt.Errorf("rsync is not installed, but it is required to import files from %s; please install rsync and try again", sshTarget)
+	}
+	if sshTarget == "" || remotePath == "" {
+		return fmt.Errorf("both an ssh target and a remote path are required to import files")
+	}
+
+	destPath := app.GetHostUploadDirFullPath()
+	if destPath == "" {
+		return fmt.Errorf("upload_dir is not set for this project, so files can't be imported")
+	}
+
+	app.DockerEnv()
+
+	if err := app.ProcessHooks("pre-import-files"); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(destPath, 0755); err != nil {
+		return fmt.Errorf("failed to create upload directory %s: %v", destPath, err)
+	}
+
+	// Trailing slashes make rsync copy the contents of remotePath into destPath
+	// rather than creating remotePath's last element inside destPath.
+	args := []string{"-az", "-e", "ssh", sshTarget + ":" + strings.TrimSuffix(remotePath, "/") + "/", destPath + "/"}
+	util.Success("Importing files from %s:%s to %s", sshTarget, remotePath, destPath)
+	if err := exec.RunInteractiveCommand("rsync", args); err != nil {
+		return fmt.Errorf("failed to rsync files from %s:%s: %v", sshTarget, remotePath, err)
+	}
+
 	//nolint: revive
 	if err := app.ProcessHooks("post-import-files"); err != nil {
 		return err
@@ -845,6 +1504,7 @@ func (app *DdevApp) GetDBImage() string {
 // Start initiates docker-compose up
 func (app *DdevApp) Start() error {
 	var err error
+	timer := newStartTimer()
 
 	app.DockerEnv()
 	volumesNeeded := []string{"ddev-global-cache", "ddev-" + app.Name + "-snapshots"}
@@ -857,11 +1517,21 @@ func (app *DdevApp) Start() error {
 
 	app.DBImage = app.GetDBImage()
 
+	err = app.CheckGeneratedCompose()
+	if err != nil {
+		return fmt.Errorf("%w; 'ddev debug repair-compose' regenerates it from the project's config", err)
+	}
+
 	err = app.CheckExistingAppInApproot()
 	if err != nil {
 		return err
 	}
 
+	err = app.ValidateAdditionalMounts()
+	if err != nil {
+		return err
+	}
+
 	// The .ddev directory may still need to be populated, especially in tests
 	err = PopulateExamplesCommandsHomeadditions(app.Name)
 	if err != nil {
@@ -889,10 +1559,12 @@ func (app *DdevApp) Start() error {
 		return err
 	}
 
+	timer.step("prepare")
 	err = app.PullContainerImages()
 	if err != nil {
 		return err
 	}
+	timer.step("pull")
 
 	dockerutil.CheckAvailableSpace()
 
@@ -934,6 +1606,12 @@ func (app *DdevApp) Start() error {
 	if err != nil {
 		return fmt.Errorf("failed to RunSimpleContainer to chown volumes: %v, output=%s", err, out)
 	}
+	if app.DBReplicaEnabled {
+		_, out, err = dockerutil.RunSimpleContainer(version.GetWebImage(), "", []string{"sh", "-c", fmt.Sprintf("chown -R %s /var/lib/mysql", uid)}, []string{}, []string{}, []string{app.GetDBReplicaVolumeName() + ":/var/lib/mysql"}, "", true, false, nil)
+		if err != nil {
+			return fmt.Errorf("failed to RunSimpleContainer to chown replica volume: %v, output=%s", err, out)
+		}
+	}
 
 	if !nodeps.ArrayContainsString(app.GetOmittedContainers(), "ddev-ssh-agent") {
 		err = app.EnsureSSHAgentContainer()
@@ -983,6 +1661,13 @@ func (app *DdevApp) Start() error {
 			}
 		}
 	}
+	if app.ValidateComposeOnStart {
+		err = app.ValidateCompose()
+		if err != nil {
+			return err
+		}
+	}
+
 	// WriteConfig .ddev-docker-compose-*.yaml
 	err = app.WriteDockerComposeYAML()
 	if err != nil {
@@ -1003,6 +1688,11 @@ func (app *DdevApp) Start() error {
 	// We don't care if the volume wasn't there
 	_ = dockerutil.RemoveVolume(app.GetNFSMountVolumeName())
 
+	err = dockerutil.EnsureNetwork(dockerutil.GetDockerClient(), app.NetworkName())
+	if err != nil {
+		return fmt.Errorf("failed to ensure docker network %s: %v", app.NetworkName(), err)
+	}
+
 	// The db_snapshots subdirectory may be created on docker-compose up, so
 	// we need to precreate it so permissions are correct (and not root:root)
 	err = os.MkdirAll(app.GetConfigPath("db_snapshots"), 0777)
@@ -1010,10 +1700,23 @@ func (app *DdevApp) Start() error {
 		return err
 	}
 
-	_, _, err = dockerutil.ComposeCmd([]string{app.DockerComposeFullRenderedYAMLPath()}, "up", "--build", "-d")
+	upArgs := []string{"up", "--build", "-d"}
+	stale, err := app.ContainersAreStale()
+	if err != nil {
+		return err
+	}
+	if stale {
+		util.Warning("Project configuration or ddev version has changed since the containers were created, recreating them")
+		upArgs = append(upArgs, "--force-recreate")
+	} else if app.GetExistingContainersPolicy() == ExistingContainersRecreate {
+		upArgs = append(upArgs, "--force-recreate")
+	}
+	timer.step("prepare")
+	_, _, err = dockerutil.ComposeCmd([]string{app.DockerComposeFullRenderedYAMLPath()}, upArgs...)
 	if err != nil {
 		return err
 	}
+	timer.step("compose_up")
 
 	if app.IsMutagenEnabled() {
 		// Must wait for web container to be healthy before fiddling with mutagen
@@ -1056,6 +1759,7 @@ func (app *DdevApp) Start() error {
 		} else {
 			util.Error("Mutagen sync completed with problems in %s.\nFor details on sync status 'ddev mutagen status %s --verbose'", dur, MutagenSyncName(app.Name))
 		}
+		timer.step("mutagen")
 	}
 
 	if !IsRouterDisabled(app) {
@@ -1063,61 +1767,226 @@ func (app *DdevApp) Start() error {
 		if err != nil {
 			return err
 		}
+		if app.NetworkName() != dockerutil.NetName {
+			err = ConnectRouterToNetwork(app.NetworkName())
+			if err != nil {
+				return err
+			}
+		}
 	}
 
+	timer.step("router")
+
 	err = app.WaitByLabels(map[string]string{"com.ddev.site-name": app.GetName()})
 	if err != nil {
 		return err
 	}
+	timer.step("wait")
+
+	if app.DBReplicaEnabled {
+		err = app.StartDBReplication()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = app.CreateMultisiteDatabases()
+	if err != nil {
+		return err
+	}
+
+	if _, err = app.CreateSettingsFile(); err != nil {
+		return fmt.Errorf("failed to write settings file %s: %v", app.SiteDdevSettingsFile, err)
+	}
+
+	err = app.PostStartAction()
+	if err != nil {
+		return err
+	}
+
+	err = app.ProcessHooks("post-start")
+	if err != nil {
+		return err
+	}
+
+	app.Warmup()
+	timer.step("post_start")
+
+	if err = app.writeStartTimings(timer.finish()); err != nil {
+		util.Warning("Unable to record start timings: %v", err)
+	}
+
+	return nil
+}
+
+// Restart does a Stop() and a Start
+func (app *DdevApp) Restart() error {
+	err := app.Stop(false, false)
+	if err != nil {
+		return err
+	}
+	err = app.Start()
+	return err
+}
+
+// Recreate removes all of the project's containers, keeping its volumes,
+// and starts it again from freshly generated docker-compose files, waiting
+// for the containers to become healthy. It works on a stopped or broken
+// project as well as on a running one.
+func (app *DdevApp) Recreate() error {
+	err := app.ForceStop(false, false)
+	if err != nil {
+		return fmt.Errorf("failed to remove the containers of project %s: %v", app.Name, err)
+	}
+	for _, f := range []string{app.DockerComposeYAMLPath(), app.DockerComposeFullRenderedYAMLPath()} {
+		if err = os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove generated %s: %v", f, err)
+		}
+	}
+	err = app.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start project %s after removing its containers: %v", app.Name, err)
+	}
+	return nil
+}
+
+// RestartService restarts a single service of a running project,
+// leaving the other containers alone. The web service is reloaded in place
+// (webserver and php-fpm) when possible, and only restarted if that fails.
+func (app *DdevApp) RestartService(service string) error {
+	if app.SiteStatus() != SiteRunning {
+		return fmt.Errorf("project %s is not running, so its %s service can't be restarted", app.Name, service)
+	}
+	container, err := app.FindContainerByType(service)
+	if err != nil {
+		return err
+	}
+	if container == nil {
+		return fmt.Errorf("project %s has no %s service", app.Name, service)
+	}
 
-	if _, err = app.CreateSettingsFile(); err != nil {
-		return fmt.Errorf("failed to write settings file %s: %v", app.SiteDdevSettingsFile, err)
+	if service == "web" {
+		reloadCmd := ""
+		switch app.WebserverType {
+		case nodeps.WebserverNginxFPM:
+			reloadCmd = "nginx -s reload && pkill -USR2 php-fpm"
+		case nodeps.WebserverApacheFPM:
+			reloadCmd = "apache2ctl -k graceful && pkill -USR2 php-fpm"
+		}
+		if reloadCmd != "" {
+			_, stderr, err := app.Exec(&ExecOpts{
+				Service: service,
+				Cmd:     reloadCmd,
+			})
+			if err == nil {
+				util.Success("Reloaded %s service of project %s", service, app.Name)
+				return nil
+			}
+			util.Warning("Unable to reload %s service (%v, stderr=%s), restarting it instead", service, err, stderr)
+		}
 	}
 
-	err = app.PostStartAction()
+	app.DockerEnv()
+	_, _, err = dockerutil.ComposeCmd([]string{app.DockerComposeFullRenderedYAMLPath()}, "restart", service)
 	if err != nil {
 		return err
 	}
-
-	err = app.ProcessHooks("post-start")
+	err = app.Wait([]string{service})
 	if err != nil {
 		return err
 	}
+	util.Success("Restarted %s service of project %s", service, app.Name)
+	return nil
+}
 
+// ClearPHPCache resets the opcache and other in-memory PHP caches of the web
+// container by gracefully reloading php-fpm, which starts fresh workers.
+func (app *DdevApp) ClearPHPCache() error {
+	if app.SiteStatus() != SiteRunning {
+		return fmt.Errorf("project %s is not running, so its PHP cache can't be cleared", app.Name)
+	}
+	_, stderr, err := app.Exec(&ExecOpts{
+		Service: "web",
+		Cmd:     "pkill -USR2 php-fpm",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reload php-fpm: %v, stderr=%s", err, stderr)
+	}
+	util.Success("Cleared PHP cache of project %s", app.Name)
 	return nil
 }
 
-// Restart does a Stop() and a Start
-func (app *DdevApp) Restart() error {
-	err := app.Stop(false, false)
+// RecreateDB replaces the db container with a new one built from the
+// current project configuration, for example after changing
+// mariadb_version. The database volume is kept, so the data survives.
+func (app *DdevApp) RecreateDB() error {
+	if nodeps.ArrayContainsString(app.GetOmittedContainers(), nodeps.DBContainer) {
+		return fmt.Errorf("project %s doesn't have a db container because it is in omit_containers", app.Name)
+	}
+	if app.SiteStatus() != SiteRunning {
+		return fmt.Errorf("project %s is not running, so its db container can't be recreated", app.Name)
+	}
+
+	err := app.WriteDockerComposeYAML()
 	if err != nil {
 		return err
 	}
-	err = app.Start()
-	return err
+	app.DockerEnv()
+	_, _, err = dockerutil.ComposeCmd([]string{app.DockerComposeFullRenderedYAMLPath()}, "rm", "--stop", "--force", "db")
+	if err != nil {
+		return err
+	}
+	_, _, err = dockerutil.ComposeCmd([]string{app.DockerComposeFullRenderedYAMLPath()}, "up", "-d", "--no-deps", "db")
+	if err != nil {
+		return err
+	}
+	err = app.Wait([]string{"db"})
+	if err != nil {
+		return fmt.Errorf("db container failed to become ready: %v", err)
+	}
+	util.Success("Recreated db container of project %s", app.Name)
+	return nil
 }
 
+// pullImage is used by PullContainerImages to pull images that aren't
+// there yet. Tests replace it to see what's pulled.
+var pullImage = dockerutil.Pull
+
 // PullContainerImages pulls the main images with full output, since docker-compose up won't show enough output
 func (app *DdevApp) PullContainerImages() error {
 	containerImages := map[string]string{
-		"db":             app.GetDBImage(),
-		"dba":            app.DBAImage,
+		"db":             app.GetDBImageFromRegistry(),
+		"dba":            app.GetDBAImageFromRegistry(),
 		"ddev-ssh-agent": version.GetSSHAuthImage(),
-		"web":            app.WebImage,
+		"web":            app.GetWebImageFromRegistry(),
 		"ddev-router":    version.GetRouterImage(),
 		"busybox":        version.BusyboxImage,
 	}
+	if app.SolrEnabled {
+		containerImages[SolrService] = version.GetSolrImage()
+	}
+	if app.RedisEnabled {
+		containerImages[RedisService] = version.GetRedisImage()
+	}
 
 	omitted := app.GetOmittedContainers()
-	for containerName, imageName := range containerImages {
+	var containerNames []string
+	for containerName := range containerImages {
 		if !nodeps.ArrayContainsString(omitted, containerName) {
-			err := dockerutil.Pull(imageName)
-			if err != nil {
-				return err
-			}
-			if globalconfig.DdevDebug {
-				output.UserOut.Printf("Pulling image for %s: %s", containerName, imageName)
-			}
+			containerNames = append(containerNames, containerName)
+		}
+	}
+	sort.Strings(containerNames)
+
+	for _, containerName := range containerNames {
+		imageName := containerImages[containerName]
+		if globalconfig.DdevDebug {
+			output.UserOut.Printf("Pulling image for %s: %s", containerName, imageName)
+		}
+		// Pull does nothing if the image is already there
+		err := pullImage(imageName)
+		if err != nil {
+			return fmt.Errorf("failed to pull image %s for %s: %v", imageName, containerName, err)
 		}
 	}
 
@@ -1135,6 +2004,22 @@ func (app *DdevApp) CheckExistingAppInApproot() error {
 	return nil
 }
 
+// CheckMountWritable makes sure the web container can write to the
+// project's mounted docroot, by creating and removing a file in it.
+func (app *DdevApp) CheckMountWritable() error {
+	containerDocroot := path.Join(app.ContainerWorkingDir(), app.Docroot)
+	_, stderr, err := app.Exec(&ExecOpts{
+		Service: "web",
+		Cmd:     fmt.Sprintf(`tmpfile=$(mktemp -p %s .ddev-write-check.XXXXXX) && rm -f "${tmpfile}"`, containerDocroot),
+	})
+	if err != nil {
+		hostDocroot := filepath.Join(app.AppRoot, app.Docroot)
+		uid, _, _ := util.GetContainerUIDGid()
+		return fmt.Errorf("the web container can't write to %s (%v: %s); make sure %s on the host is writable by your user (uid %s), for example with 'chmod -R u+w %s'", containerDocroot, err, strings.TrimSpace(stderr), hostDocroot, uid, hostDocroot)
+	}
+	return nil
+}
+
 //go:embed webserver_config_assets
 var webserverConfigAssets embed.FS
 
@@ -1182,7 +2067,14 @@ func (app *DdevApp) GenerateWebserverConfig() error {
 		}
 		content := string(c)
 		docroot := path.Join("/var/www/html", app.Docroot)
-		err = fileutil.TemplateStringToFile(content, map[string]interface{}{"Docroot": docroot}, configPath)
+		fastCGIBuffers, fastCGIBufferSize := app.GetFastCGIBufferSettings()
+		err = fileutil.TemplateStringToFile(content, map[string]interface{}{
+			"Docroot":           docroot,
+			"BasePath":          app.BasePath,
+			"DirectoryListing":  app.DirectoryListing,
+			"FastCGIBuffers":    fastCGIBuffers,
+			"FastCGIBufferSize": fastCGIBufferSize,
+		}, configPath)
 		if err != nil {
 			return err
 		}
@@ -1206,6 +2098,8 @@ type ExecOpts struct {
 	Stdout *os.File
 	// Stderr can be overridden with a File
 	Stderr *os.File
+	// Stdin can be overridden with a File, which only works with NoCapture
+	Stdin *os.File
 }
 
 // Exec executes a given command in the container of given type without allocating a pty
@@ -1268,8 +2162,12 @@ func (app *DdevApp) Exec(opts *ExecOpts) (string, string, error) {
 		return "", "", err
 	}
 
+	stdin := os.Stdin
 	stdout := os.Stdout
 	stderr := os.Stderr
+	if opts.Stdin != nil {
+		stdin = opts.Stdin
+	}
 	if opts.Stdout != nil {
 		stdout = opts.Stdout
 	}
@@ -1279,7 +2177,7 @@ func (app *DdevApp) Exec(opts *ExecOpts) (string, string, error) {
 
 	var stdoutResult, stderrResult string
 	if opts.NoCapture || opts.Tty {
-		err = dockerutil.ComposeWithStreams(files, os.Stdin, stdout, stderr, args...)
+		err = dockerutil.ComposeWithStreams(files, stdin, stdout, stderr, args...)
 	} else {
 		stdoutResult, stderrResult, err = dockerutil.ComposeCmd([]string{app.DockerComposeFullRenderedYAMLPath()}, args...)
 	}
@@ -1509,29 +2407,42 @@ func (app *DdevApp) DockerEnv() {
 	// 2. To tell custom commands the db port. And it's expected always to be populated for them.
 	dbPort, err := app.GetPublishedPort("db")
 	dbPortStr := strconv.Itoa(dbPort)
-	if dbPortStr == "-1" || err != nil {
+	if dbPort <= 0 || err != nil {
 		dbPortStr = ""
 	}
 	if app.HostDBPort != "" {
 		dbPortStr = app.HostDBPort
 	}
 
+	// An empty DDEV_HOST_SOLR_PORT means docker chooses a free port.
+	solrPortStr := ""
+	if app.SolrEnabled {
+		solrPort, err := app.GetPublishedPort(SolrService)
+		if err == nil && solrPort > 0 {
+			solrPortStr = strconv.Itoa(solrPort)
+		}
+		if app.HostSolrPort != "" {
+			solrPortStr = app.HostSolrPort
+		}
+	}
+
 	envVars := map[string]string{
 		// Without COMPOSE_DOCKER_CLI_BUILD=0, docker-compose makes all kinds of mess
 		// of output. BUILDKIT_PROGRESS doesn't help either.
 		"COMPOSE_DOCKER_CLI_BUILD":      "0",
-		"COMPOSE_PROJECT_NAME":          "ddev-" + app.Name,
+		"COMPOSE_PROJECT_NAME":          app.ProjectName(),
 		"COMPOSE_CONVERT_WINDOWS_PATHS": "true",
 		"DDEV_SITENAME":                 app.Name,
 		"DDEV_TLD":                      app.ProjectTLD,
-		"DDEV_DBIMAGE":                  app.GetDBImage(),
-		"DDEV_DBAIMAGE":                 app.DBAImage,
+		"DDEV_DBIMAGE":                  app.GetDBImageFromRegistry(),
+		"DDEV_DBAIMAGE":                 app.GetDBAImageFromRegistry(),
 		"DDEV_PROJECT":                  app.Name,
-		"DDEV_WEBIMAGE":                 app.WebImage,
+		"DDEV_WEBIMAGE":                 app.GetWebImageFromRegistry(),
 		"DDEV_APPROOT":                  app.AppRoot,
 		"DDEV_FILES_DIR":                app.GetContainerUploadDirFullPath(),
 
 		"DDEV_HOST_DB_PORT":          dbPortStr,
+		"DDEV_HOST_SOLR_PORT":        solrPortStr,
 		"DDEV_HOST_WEBSERVER_PORT":   app.HostWebserverPort,
 		"DDEV_HOST_HTTPS_PORT":       app.HostHTTPSPort,
 		"DDEV_PHPMYADMIN_PORT":       app.PHPMyAdminPort,
@@ -1588,7 +2499,12 @@ func (app *DdevApp) Pause() error {
 		return nil
 	}
 
-	err := app.ProcessHooks("pre-pause")
+	err := CheckContainersOwnership(app)
+	if err != nil {
+		return err
+	}
+
+	err = app.ProcessHooks("pre-pause")
 	if err != nil {
 		return err
 	}
@@ -1646,6 +2562,40 @@ func (app *DdevApp) Wait(requiredContainers []string) error {
 	return nil
 }
 
+// waitStatusInterval is how often WaitWithOutput repeats the status of a
+// service whose health hasn't changed
+var waitStatusInterval = 5 * time.Second
+
+// WaitWithOutput is like Wait() for the web and db containers, but writes
+// status lines to w while it waits for each of them to become healthy.
+func (app *DdevApp) WaitWithOutput(w io.Writer) error {
+	services := []string{"web"}
+	if !nodeps.ArrayContainsString(app.GetOmittedContainers(), "db") {
+		services = append(services, "db")
+	}
+	for _, containerType := range services {
+		labels := map[string]string{
+			"com.ddev.site-name":         app.GetName(),
+			"com.docker.compose.service": containerType,
+		}
+		_, _ = fmt.Fprintf(w, "Waiting for %s to become ready...\n", containerType)
+		lastHealth := ""
+		lastReport := time.Now()
+		logOutput, err := dockerutil.ContainerWaitWithProgress(containerWaitTimeout, labels, func(health string) {
+			if health != lastHealth || time.Since(lastReport) >= waitStatusInterval {
+				_, _ = fmt.Fprintf(w, "Waiting for %s, status=%s\n", containerType, health)
+				lastHealth = health
+				lastReport = time.Now()
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("%s container failed: log=%s, err=%v", containerType, logOutput, err)
+		}
+		_, _ = fmt.Fprintf(w, "%s is ready\n", containerType)
+	}
+	return nil
+}
+
 // WaitByLabels waits for containers found by list of labels to be
 // ready
 func (app *DdevApp) WaitByLabels(labels map[string]string) error {
@@ -1795,18 +2745,21 @@ func (app *DdevApp) Snapshot(baseSnapshotName string) (string, error) {
 
 // DeleteSnapshot removes the snapshot directory inside a project
 func (app *DdevApp) DeleteSnapshot(snapshotName string) error {
-	var err error
-	err = app.ProcessHooks("pre-delete-snapshot")
-	if err != nil {
-		return fmt.Errorf("failed to process pre-delete-snapshot hooks: %v", err)
+	if snapshotName == "" || snapshotName != filepath.Base(snapshotName) || snapshotName == "." || snapshotName == ".." {
+		return fmt.Errorf("invalid snapshot name '%s'", snapshotName)
 	}
-
 	snapshot := path.Join("db_snapshots", snapshotName)
 	hostSnapshot := app.GetConfigPath(snapshot)
 
 	if !fileutil.FileExists(hostSnapshot) {
-		return fmt.Errorf("no snapshot '%s' currently exists in project '%s'", snapshotName, app.Name)
+		return fmt.Errorf("%w: no snapshot '%s' currently exists in project '%s'", ErrSnapshotNotFound, snapshotName, app.Name)
+	}
+
+	err := app.ProcessHooks("pre-delete-snapshot")
+	if err != nil {
+		return fmt.Errorf("failed to process pre-delete-snapshot hooks: %v", err)
 	}
+
 	if err = os.RemoveAll(hostSnapshot); err != nil {
 		return fmt.Errorf("failed to remove snapshot '%s': %v", hostSnapshot, err)
 	}
@@ -1836,11 +2789,37 @@ func (app *DdevApp) GetLatestSnapshot() (string, error) {
 	return snapshots[0], nil
 }
 
+// SnapshotInfo describes one of the project's database snapshots
+type SnapshotInfo struct {
+	// Name is what RestoreSnapshot and DeleteSnapshot take
+	Name string
+	// Created is when the snapshot was made
+	Created time.Time
+	// Size is the number of bytes the snapshot takes on disk
+	Size int64
+}
+
 // ListSnapshots returns a list of the names of all project snapshots
 func (app *DdevApp) ListSnapshots() ([]string, error) {
-	var err error
 	var snapshots []string
 
+	infos, err := app.Snapshots()
+	if err != nil {
+		return snapshots, err
+	}
+	for _, info := range infos {
+		snapshots = append(snapshots, info.Name)
+	}
+
+	return snapshots, nil
+}
+
+// Snapshots returns the project's snapshots with when they were made and
+// their size, the latest first
+func (app *DdevApp) Snapshots() ([]SnapshotInfo, error) {
+	var err error
+	var snapshots []SnapshotInfo
+
 	snapshotDir := app.GetConfigPath("db_snapshots")
 
 	if !fileutil.FileExists(snapshotDir) {
@@ -1869,14 +2848,42 @@ func (app *DdevApp) ListSnapshots() ([]string, error) {
 	})
 
 	for _, f := range files {
-		if f.IsDir() || strings.HasSuffix(f.Name(), ".gz") {
-			snapshots = append(snapshots, f.Name())
+		if !f.IsDir() && !strings.HasSuffix(f.Name(), ".gz") {
+			continue
 		}
+		size := f.Size()
+		if f.IsDir() {
+			// Older snapshots are directories of mariabackup files
+			size, err = dirSize(filepath.Join(snapshotDir, f.Name()))
+			if err != nil {
+				return snapshots, err
+			}
+		}
+		snapshots = append(snapshots, SnapshotInfo{Name: f.Name(), Created: f.ModTime(), Size: size})
 	}
 
 	return snapshots, nil
 }
 
+// dirSize returns the total size of the files in dir
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
 // RestoreSnapshot restores a mariadb snapshot of the db to be loaded
 // The project must be stopped and docker volume removed and recreated for this to work.
 func (app *DdevApp) RestoreSnapshot(snapshotName string) error {
@@ -1886,52 +2893,9 @@ func (app *DdevApp) RestoreSnapshot(snapshotName string) error {
 		return fmt.Errorf("failed to process pre-restore-snapshot hooks: %v", err)
 	}
 
-	currentDBVersion := "mariadb_" + nodeps.MariaDBDefaultVersion
-	if app.MariaDBVersion != "" {
-		currentDBVersion = "mariadb_" + app.MariaDBVersion
-	} else if app.MySQLVersion != "" {
-		currentDBVersion = "mysql_" + app.MySQLVersion
-	}
-
-	snapshotFileOrDir := filepath.Join("db_snapshots", snapshotName)
-
-	hostSnapshotFileOrDir := app.GetConfigPath(snapshotFileOrDir)
-
-	if !fileutil.FileExists(hostSnapshotFileOrDir) {
-		return fmt.Errorf("failed to find a snapshot at %s", hostSnapshotFileOrDir)
-	}
-
-	snapshotDBVersion := ""
-
-	// If the snapshot is a directory, (old obsolete style) then
-	// look for db_mariadb_version.txt in the directory to get the version.
-	if fileutil.IsDirectory(hostSnapshotFileOrDir) {
-		// Find out the mariadb version that correlates to the snapshot.
-		versionFile := filepath.Join(hostSnapshotFileOrDir, "db_mariadb_version.txt")
-		if fileutil.FileExists(versionFile) {
-			snapshotDBVersion, err = fileutil.ReadFileIntoString(versionFile)
-			if err != nil {
-				return fmt.Errorf("unable to read the version file in the snapshot (%s): %v", versionFile, err)
-			}
-			snapshotDBVersion = strings.Trim(snapshotDBVersion, "\r\n\t ")
-			snapshotDBVersion = fullDBFromVersion(snapshotDBVersion)
-		} else {
-			snapshotDBVersion = "unknown"
-		}
-	} else {
-		base := strings.TrimSuffix(snapshotName, ".gz")
-		parts := strings.Split(base, "-")
-		if len(parts) < 2 {
-			return fmt.Errorf("unable to determine database type/version from snapshot name %s", snapshotName)
-		}
-		snapshotDBVersion = parts[len(parts)-1]
-		if !(strings.HasPrefix(snapshotDBVersion, "mariadb_") || strings.HasPrefix(snapshotDBVersion, "mysql_")) {
-			return fmt.Errorf("unable to determine database type/version from snapshot name %s", snapshotName)
-		}
-	}
-
-	if snapshotDBVersion != currentDBVersion {
-		return fmt.Errorf("snapshot '%s' is a DB server '%s' snapshot and is not compatible with the configured ddev DB server version (%s).  Please restore it using the DB version it was created with, and then you can try upgrading the ddev DB version", snapshotName, snapshotDBVersion, currentDBVersion)
+	hostSnapshotFileOrDir, err := app.checkSnapshotCompatible(snapshotName)
+	if err != nil {
+		return err
 	}
 
 	if app.SiteStatus() == SiteRunning || app.SiteStatus() == SitePaused {
@@ -1999,6 +2963,67 @@ func (app *DdevApp) RestoreSnapshot(snapshotName string) error {
 	return nil
 }
 
+// checkSnapshotCompatible makes sure a snapshot exists and was made with
+// the project's database server type and version. It returns the path of
+// the snapshot on the host.
+func (app *DdevApp) checkSnapshotCompatible(snapshotName string) (string, error) {
+	var err error
+	currentDBVersion := app.fullDBVersion()
+
+	snapshotFileOrDir := filepath.Join("db_snapshots", snapshotName)
+
+	hostSnapshotFileOrDir := app.GetConfigPath(snapshotFileOrDir)
+
+	if !fileutil.FileExists(hostSnapshotFileOrDir) {
+		return "", fmt.Errorf("failed to find a snapshot at %s", hostSnapshotFileOrDir)
+	}
+
+	snapshotDBVersion := ""
+
+	// If the snapshot is a directory, (old obsolete style) then
+	// look for db_mariadb_version.txt in the directory to get the version.
+	if fileutil.IsDirectory(hostSnapshotFileOrDir) {
+		// Find out the mariadb version that correlates to the snapshot.
+		versionFile := filepath.Join(hostSnapshotFileOrDir, "db_mariadb_version.txt")
+		if fileutil.FileExists(versionFile) {
+			snapshotDBVersion, err = fileutil.ReadFileIntoString(versionFile)
+			if err != nil {
+				return "", fmt.Errorf("unable to read the version file in the snapshot (%s): %v", versionFile, err)
+			}
+			snapshotDBVersion = strings.Trim(snapshotDBVersion, "\r\n\t ")
+			snapshotDBVersion = fullDBFromVersion(snapshotDBVersion)
+		} else {
+			snapshotDBVersion = "unknown"
+		}
+	} else {
+		base := strings.TrimSuffix(snapshotName, ".gz")
+		parts := strings.Split(base, "-")
+		if len(parts) < 2 {
+			return "", fmt.Errorf("unable to determine database type/version from snapshot name %s", snapshotName)
+		}
+		snapshotDBVersion = parts[len(parts)-1]
+		if !(strings.HasPrefix(snapshotDBVersion, "mariadb_") || strings.HasPrefix(snapshotDBVersion, "mysql_")) {
+			return "", fmt.Errorf("unable to determine database type/version from snapshot name %s", snapshotName)
+		}
+	}
+
+	if snapshotDBVersion != currentDBVersion {
+		return "", fmt.Errorf("snapshot '%s' is a DB server '%s' snapshot and is not compatible with the configured ddev DB server version (%s).  Please restore it using the DB version it was created with, and then you can try upgrading the ddev DB version", snapshotName, snapshotDBVersion, currentDBVersion)
+	}
+	return hostSnapshotFileOrDir, nil
+}
+
+// fullDBVersion returns the configured db server type and version
+// like "mariadb_10.3", the way db_mariadb_version.txt records it.
+func (app *DdevApp) fullDBVersion() string {
+	if app.MariaDBVersion != "" {
+		return "mariadb_" + app.MariaDBVersion
+	} else if app.MySQLVersion != "" {
+		return "mysql_" + app.MySQLVersion
+	}
+	return "mariadb_" + nodeps.MariaDBDefaultVersion
+}
+
 // fullDBFromVersion takes just a mariadb or mysql version number
 // in x.xx format and returns something like mariadb-10.5
 func fullDBFromVersion(v string) string {
@@ -2020,16 +3045,36 @@ func fullDBFromVersion(v string) string {
 }
 
 // Stop stops and Removes the docker containers for the project in current directory.
+// If a container can't be removed it stops there and returns a *StopError
+// listing the containers that were and weren't removed.
 func (app *DdevApp) Stop(removeData bool, createSnapshot bool) error {
+	return app.stop(removeData, createSnapshot, false)
+}
+
+// ForceStop is like Stop, but it goes on past containers that can't be
+// removed and failing hooks, removing everything it can. The returned
+// *StopError lists the containers that are left.
+func (app *DdevApp) ForceStop(removeData bool, createSnapshot bool) error {
+	return app.stop(removeData, createSnapshot, true)
+}
+
+func (app *DdevApp) stop(removeData bool, createSnapshot bool, force bool) error {
 	app.DockerEnv()
 	var err error
 
 	if app.Name == "" {
 		return fmt.Errorf("invalid app.Name provided to app.Stop(), app=%v", app)
 	}
+	err = CheckContainersOwnership(app)
+	if err != nil {
+		return err
+	}
 	err = app.ProcessHooks("pre-stop")
 	if err != nil {
-		return fmt.Errorf("failed to process pre-stop hooks: %v", err)
+		if !force {
+			return fmt.Errorf("failed to process pre-stop hooks: %v", err)
+		}
+		util.Warning("Failed to process pre-stop hooks, continuing: %v", err)
 	}
 
 	if createSnapshot == true {
@@ -2059,14 +3104,16 @@ func (app *DdevApp) Stop(removeData bool, createSnapshot bool) error {
 		}
 	}
 	// Remove all the containers and volumes for app.
-	err = Cleanup(app)
-	if err != nil {
-		return err
+	cleanupErr := cleanup(app, force)
+	if cleanupErr != nil && !force {
+		return cleanupErr
 	}
 
 	// Remove data/database/projectInfo/hostname if we need to.
 	if removeData {
-		if err = app.RemoveHostsEntries(); err != nil {
+		if app.DisableHostsManagement {
+			util.Debug("disable_hosts_management is set, so hosts entries aren't removed")
+		} else if err = app.RemoveHostsEntries(); err != nil {
 			return fmt.Errorf("failed to remove hosts entries: %v", err)
 		}
 		app.RemoveGlobalProjectInfo()
@@ -2075,7 +3122,7 @@ func (app *DdevApp) Stop(removeData bool, createSnapshot bool) error {
 			util.Warning("could not WriteGlobalConfig: %v", err)
 		}
 
-		vols := []string{app.Name + "-mariadb", GetMutagenVolumeName(app)}
+		vols := []string{app.Name + "-mariadb", app.GetDBReplicaVolumeName(), GetMutagenVolumeName(app)}
 		if globalconfig.DdevGlobalConfig.NoBindMounts {
 			vols = append(vols, app.Name+"-ddev-config")
 		}
@@ -2089,7 +3136,7 @@ func (app *DdevApp) Stop(removeData bool, createSnapshot bool) error {
 		}
 		deleteServiceVolumes(app)
 
-		dbBuilt := app.GetDBImage() + "-" + app.Name + "-built"
+		dbBuilt := app.GetDBImageFromRegistry() + "-" + app.Name + "-built"
 		_ = dockerutil.RemoveImage(dbBuilt)
 
 		webBuilt := version.GetWebImage() + "-" + app.Name + "-built"
@@ -2099,10 +3146,13 @@ func (app *DdevApp) Stop(removeData bool, createSnapshot bool) error {
 
 	err = app.ProcessHooks("post-stop")
 	if err != nil {
-		return fmt.Errorf("failed to process post-stop hooks: %v", err)
+		if !force {
+			return fmt.Errorf("failed to process post-stop hooks: %v", err)
+		}
+		util.Warning("Failed to process post-stop hooks: %v", err)
 	}
 
-	return nil
+	return cleanupErr
 }
 
 // deleteServiceVolumes finds all the volumes created by services and removes them.
@@ -2215,6 +3265,40 @@ func (app *DdevApp) GetPrimaryURL() string {
 	return ""
 }
 
+// URL returns the primary URL of the project including its base_path,
+// which is where the site itself is served.
+func (app *DdevApp) URL() string {
+	primaryURL := app.GetPrimaryURL()
+	if primaryURL == "" {
+		return ""
+	}
+	return primaryURL + app.BasePath
+}
+
+// Ports returns the published host port of each of the project's running
+// services that has one, by service name. If a service publishes several
+// ports, the one for its lowest container port is used, so web maps to the
+// host port of http, not https.
+func (app *DdevApp) Ports() (map[string]int, error) {
+	containers, err := dockerutil.GetAppContainers(app.Name)
+	if err != nil {
+		return nil, err
+	}
+	ports := map[string]int{}
+	for _, c := range containers {
+		service := c.Labels["com.docker.compose.service"]
+		lowestPrivatePort := int64(0)
+		for _, p := range c.Ports {
+			if p.PublicPort == 0 || (lowestPrivatePort != 0 && p.PrivatePort >= lowestPrivatePort) {
+				continue
+			}
+			lowestPrivatePort = p.PrivatePort
+			ports[service] = int(p.PublicPort)
+		}
+	}
+	return ports, nil
+}
+
 // GetWebContainerDirectHTTPURL returns the URL that can be used without the router to get to web container.
 func (app *DdevApp) GetWebContainerDirectHTTPURL() string {
 	// Get direct address of web container
@@ -2314,8 +3398,12 @@ func (app *DdevApp) AddHostsEntriesIfNeeded() error {
 		if hosts.Has(dockerIP, name) {
 			continue
 		}
+		if app.DisableHostsManagement {
+			util.Warning("The hostname %s is not currently resolvable and disable_hosts_management is set, so it isn't added to the hosts file; the project can be reached at %s", name, app.GetWebContainerDirectHTTPURL())
+			continue
+		}
 		util.Warning("The hostname %s is not currently resolvable, trying to add it to the hosts file", name)
-		err = addHostEntry(name, dockerIP)
+		err = AddHostEntry(name, dockerIP)
 		if err != nil {
 			return err
 		}
@@ -2324,6 +3412,14 @@ func (app *DdevApp) AddHostsEntriesIfNeeded() error {
 	return nil
 }
 
+// AddHostEntry adds name with ip to the hosts file. It can be replaced in
+// tests to keep the hosts file from being changed.
+var AddHostEntry = addHostEntry
+
+// RemoveHostEntry removes name with ip from the hosts file. It can be
+// replaced in tests to keep the hosts file from being changed.
+var RemoveHostEntry = removeHostEntry
+
 // addHostEntry adds an entry to /etc/hosts
 // We would have hoped to use DNS or have found the entry already in hosts
 // But if it's not, try to add one.
@@ -2379,21 +3475,29 @@ func (app *DdevApp) RemoveHostsEntries() error {
 			return nil
 		}
 
-		ddevFullPath, err := os.Executable()
-		util.CheckErr(err)
+		if err = RemoveHostEntry(name, dockerIP); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
-		output.UserOut.Printf("ddev needs to remove an entry from your hosts file.\nIt will require administrative privileges via the sudo command, so you may be required\nto enter your password for sudo. ddev is about to issue the command:")
+// removeHostEntry removes an entry from /etc/hosts using sudo
+func removeHostEntry(name string, ip string) error {
+	ddevFullPath, err := os.Executable()
+	util.CheckErr(err)
 
-		hostnameArgs := []string{ddevFullPath, "hostname", "--remove", name, dockerIP}
-		command := strings.Join(hostnameArgs, " ")
-		util.Warning(fmt.Sprintf("    sudo %s", command))
-		output.UserOut.Println("Please enter your password if prompted.")
+	output.UserOut.Printf("ddev needs to remove an entry from your hosts file.\nIt will require administrative privileges via the sudo command, so you may be required\nto enter your password for sudo. ddev is about to issue the command:")
 
-		if _, err = exec.RunCommandPipe("sudo", hostnameArgs); err != nil {
-			util.Warning("Failed to execute sudo command, you will need to manually execute '%s' with administrative privileges", command)
-		}
-	}
+	hostnameArgs := []string{ddevFullPath, "hostname", "--remove", name, ip}
+	command := strings.Join(hostnameArgs, " ")
+	util.Warning(fmt.Sprintf("    sudo %s", command))
+	output.UserOut.Println("Please enter your password if prompted.")
 
+	if _, err = exec.RunCommandPipe("sudo", hostnameArgs); err != nil {
+		util.Warning("Failed to execute sudo command, you will need to manually execute '%s' with administrative privileges", command)
+	}
 	return nil
 }
 
@@ -2521,6 +3625,26 @@ func (app *DdevApp) GetWorkingDir(service string, dir string) string {
 	return app.DefaultWorkingDirMap()[service]
 }
 
+// NetworkName returns the name of the docker network the project's
+// containers share with the router, network_name if it's set
+func (app *DdevApp) NetworkName() string {
+	if app.DockerNetworkName != "" {
+		return app.DockerNetworkName
+	}
+	return dockerutil.NetName
+}
+
+// composeProjectNameInvalidChars are the characters docker-compose drops
+// from project names, after lowercasing them
+var composeProjectNameInvalidChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// ProjectName returns the docker-compose project name the project's
+// containers, networks and volumes are created with, "ddev-<name>" the way
+// docker-compose normalizes it.
+func (app *DdevApp) ProjectName() string {
+	return composeProjectNameInvalidChars.ReplaceAllString(strings.ToLower("ddev-"+app.Name), "")
+}
+
 // GetNFSMountVolumeName returns the docker volume name of the nfs mount volume
 func (app *DdevApp) GetNFSMountVolumeName() string {
 	// This is lowercased because the automatic naming in docker-compose v1/2
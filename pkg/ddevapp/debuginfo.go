@@ -0,0 +1,95 @@
+package ddevapp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/drud/ddev/pkg/nodeps"
+	"github.com/drud/ddev/pkg/version"
+)
+
+// debugInfoMask replaces secrets in DebugInfo output
+const debugInfoMask = "********"
+
+// DebugInfo returns a summary of what ddev detected and generated for the
+// project, suitable for pasting into a bug report. The db password and the
+// values of web_environment are masked.
+func (app *DdevApp) DebugInfo() (string, error) {
+	if app.AppRoot == "" {
+		return "", fmt.Errorf("project has not been initialized")
+	}
+
+	var lines []string
+	add := func(key string, value interface{}) {
+		lines = append(lines, fmt.Sprintf("%s: %v", key, value))
+	}
+
+	add("ddev_version", version.DdevVersion)
+	add("name", app.Name)
+	add("compose_project", app.ProjectName())
+	add("status", app.SiteStatus())
+	add("type", app.GetType())
+	add("detected_type", app.DetectAppType())
+	add("approot", RenderHomeRootedDir(app.GetAppRoot()))
+	add("docroot", app.GetDocroot())
+	add("php_version", app.PHPVersion)
+	add("webserver_type", app.WebserverType)
+	add("webimage", app.GetWebImageFromRegistry())
+
+	if !nodeps.ArrayContainsString(app.GetOmittedContainers(), "db") {
+		if app.MySQLVersion != "" {
+			add("database_type", nodeps.MySQL)
+			add("mysql_version", app.MySQLVersion)
+		} else {
+			add("database_type", nodeps.MariaDB)
+			dbVersion := app.MariaDBVersion
+			if dbVersion == "" {
+				dbVersion = nodeps.MariaDBDefaultVersion
+			}
+			add("mariadb_version", dbVersion)
+		}
+		add("dbimage", app.GetDBImageFromRegistry())
+		add("db_username", "db")
+		add("db_password", debugInfoMask)
+	}
+	add("network", app.NetworkName())
+
+	if app.DisableSettingsManagement {
+		add("settings_management", "disabled")
+	} else {
+		add("settings_file", app.SiteSettingsPath)
+		add("settings_ddev_file", app.SiteDdevSettingsFile)
+	}
+
+	envKeys := []string{}
+	for _, env := range app.WebEnvironment {
+		envKeys = append(envKeys, strings.SplitN(env, "=", 2)[0]+"="+debugInfoMask)
+	}
+	sort.Strings(envKeys)
+	if len(envKeys) > 0 {
+		add("web_environment", strings.Join(envKeys, ", "))
+	}
+
+	services := []string{"web"}
+	if !nodeps.ArrayContainsString(app.GetOmittedContainers(), "db") {
+		services = append(services, "db")
+	}
+	for _, service := range services {
+		containerName := GetContainerName(app, service)
+		container, err := app.FindContainerByType(service)
+		if err != nil {
+			return "", err
+		}
+		if container == nil {
+			add("container."+service, containerName+" (not found)")
+			continue
+		}
+		add("container."+service, containerName+" ("+container.State+")")
+		for _, m := range container.Mounts {
+			add("mount."+service, m.Source+" -> "+m.Destination)
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
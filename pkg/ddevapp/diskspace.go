@@ -0,0 +1,80 @@
+package ddevapp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+	"github.com/drud/ddev/pkg/util"
+	"github.com/mitchellh/go-homedir"
+)
+
+// ErrInsufficientDiskSpace is returned when docker doesn't have enough
+// free space for a database import.
+var ErrInsufficientDiskSpace = errors.New("not enough docker disk space")
+
+// compressedDumpRatio is how much larger than its archive a database dump
+// is assumed to be once it's extracted.
+const compressedDumpRatio = 5
+
+// dockerAvailableSpace returns the bytes available on docker's disk. Tests
+// replace it to simulate a full disk.
+var dockerAvailableSpace = dockerutil.GetAvailableSpace
+
+// CheckDiskSpace returns the number of bytes available to docker for
+// images, containers and volumes, like the project's database.
+func (app *DdevApp) CheckDiskSpace() (freeBytes int64, err error) {
+	app.DockerEnv()
+	return dockerAvailableSpace()
+}
+
+// checkImportSpace makes sure docker has room for the database dump at
+// imPath. It fails if the estimated size of the imported database is more
+// than the free space, and warns if it would take more than half of it.
+// If the free space can't be determined the import goes ahead.
+func (app *DdevApp) checkImportSpace(imPath string) error {
+	needed, err := estimatedImportSize(imPath)
+	if err != nil {
+		return err
+	}
+	free, err := app.CheckDiskSpace()
+	if err != nil {
+		util.Warning("Unable to check docker disk space before the import: %v", err)
+		return nil
+	}
+	if free < needed {
+		return fmt.Errorf("%w: importing %s needs about %s, but docker has only %s available; please free up space or increase the docker disk size", ErrInsufficientDiskSpace, imPath, formatMB(needed), formatMB(free))
+	}
+	if free < 2*needed {
+		util.Warning("Docker disk space is low: importing %s needs about %s and only %s is available", imPath, formatMB(needed), formatMB(free))
+	}
+	return nil
+}
+
+// estimatedImportSize estimates the size of the database dump at imPath
+// once it's imported. Compressed dumps are assumed to expand by
+// compressedDumpRatio.
+func estimatedImportSize(imPath string) (int64, error) {
+	expanded, err := homedir.Expand(imPath)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(expanded)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %v", ErrImportSourceNotFound, imPath, err)
+	}
+	size := info.Size()
+	for _, suffix := range []string{".gz", ".tgz", ".zip", ".bz2", ".xz"} {
+		if strings.HasSuffix(imPath, suffix) {
+			return size * compressedDumpRatio, nil
+		}
+	}
+	return size, nil
+}
+
+// formatMB formats a number of bytes in megabytes.
+func formatMB(bytes int64) string {
+	return fmt.Sprintf("%dMB", bytes/(1024*1024))
+}
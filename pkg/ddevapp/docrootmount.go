@@ -0,0 +1,71 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+	"github.com/drud/ddev/pkg/globalconfig"
+)
+
+// GetDocrootWritableDirs returns the directories, relative to the docroot,
+// that stay writable when docroot_read_only is set. If docroot_writable_dirs
+// isn't set, that's the project's upload dir.
+func (app *DdevApp) GetDocrootWritableDirs() []string {
+	if len(app.DocrootWritableDirs) > 0 {
+		return app.DocrootWritableDirs
+	}
+	if app.GetUploadDir() != "" {
+		return []string{app.GetUploadDir()}
+	}
+	return nil
+}
+
+// validateDocrootReadOnly checks that docroot_read_only can be used with the
+// way the project is mounted and that docroot_writable_dirs are inside the docroot.
+func (app *DdevApp) validateDocrootReadOnly() error {
+	if !app.DocrootReadOnly {
+		if len(app.DocrootWritableDirs) > 0 {
+			return fmt.Errorf("docroot_writable_dirs can only be used with docroot_read_only")
+		}
+		return nil
+	}
+	switch {
+	case app.NoProjectMount:
+		return fmt.Errorf("docroot_read_only can't be used with no_project_mount")
+	case app.IsMutagenEnabled():
+		return fmt.Errorf("docroot_read_only is not supported with mutagen")
+	case app.NFSMountEnabled || app.NFSMountEnabledGlobal:
+		return fmt.Errorf("docroot_read_only is not supported with nfs_mount_enabled")
+	case globalconfig.DdevGlobalConfig.NoBindMounts:
+		return fmt.Errorf("docroot_read_only is not supported with no_bind_mounts")
+	}
+	for _, dir := range app.DocrootWritableDirs {
+		clean := path.Clean(filepath.ToSlash(dir))
+		if dir == "" || path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("invalid docroot_writable_dirs entry %q: it must be a directory inside the docroot, relative to it", dir)
+		}
+	}
+	return nil
+}
+
+// docrootWritableMounts returns the mounts of the writable dirs in a
+// read-only docroot. The host directories are created if they don't
+// exist, because docker can't create them in the read-only mount.
+func (app *DdevApp) docrootWritableMounts() ([]AdditionalMount, error) {
+	var mounts []AdditionalMount
+	for _, dir := range app.GetDocrootWritableDirs() {
+		hostDir := filepath.Join(app.AppRoot, app.Docroot, dir)
+		if err := os.MkdirAll(hostDir, 0755); err != nil {
+			return nil, fmt.Errorf("unable to create writable docroot directory %s: %v", hostDir, err)
+		}
+		mounts = append(mounts, AdditionalMount{
+			Source: dockerutil.MassageWindowsHostMountpoint(hostDir),
+			Target: path.Join("/var/www/html", app.Docroot, filepath.ToSlash(dir)),
+		})
+	}
+	return mounts, nil
+}
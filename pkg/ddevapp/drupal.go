@@ -151,6 +151,10 @@ func createDrupalSettingsPHP(app *DdevApp) (string, error) {
 		return "", fmt.Errorf("`failed to write` Drupal settings file %s: %v", app.SiteDdevSettingsFile, err)
 	}
 
+	if err := writeDrupalMultisiteSettings(app); err != nil {
+		return "", err
+	}
+
 	return app.SiteDdevSettingsFile, nil
 }
 
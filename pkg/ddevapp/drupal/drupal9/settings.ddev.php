@@ -40,4 +40,4 @@ $settings['trusted_host_patterns'] = ['.*'];
 // better performance.
 $settings['class_loader_auto_detect'] = FALSE;
 
-$settings['config_sync_directory'] = 'sites/default/files/sync';
+$settings['config_sync_directory'] = '{{ $config.SitePath }}/{{ $config.SyncDir }}';
@@ -1,5 +1,39 @@
 package ddevapp
 
+import "errors"
+
+// ErrEmptyDump is returned by ImportDB when the provided dump doesn't
+// contain any SQL statements.
+var ErrEmptyDump = errors.New("database dump is empty or contains no SQL statements")
+
+// ErrImportSourceNotFound is returned by ImportDB and ImportFiles when the
+// path to import doesn't exist or can't be read.
+var ErrImportSourceNotFound = errors.New("import source not found or not readable")
+
+// ErrDBImportUnchanged is returned by ImportDB when the database was last
+// imported from the same dump, so it wasn't imported again.
+var ErrDBImportUnchanged = errors.New("database was last imported from the same dump")
+
+// ErrSnapshotNotFound is returned by DeleteSnapshot when the project
+// has no snapshot with the given name.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// ErrInvalidCompose is returned by ValidateCompose when docker-compose
+// rejects the project's compose files.
+var ErrInvalidCompose = errors.New("invalid docker-compose configuration")
+
+// ErrImportTimeout is returned by ImportDB when the import takes longer
+// than import_timeout.
+var ErrImportTimeout = errors.New("database import timed out")
+
+// ErrCorruptCompose is returned by Start and CheckGeneratedCompose when a
+// docker-compose file generated by ddev can't be parsed.
+var ErrCorruptCompose = errors.New("generated docker-compose file is corrupt")
+
+// ErrDBPortNotExposed is returned by DBCredentials when the db port isn't
+// published to the host because of expose_db_port: false.
+var ErrDBPortNotExposed = errors.New("the database port is not published to the host")
+
 type invalidConfigFile error
 type invalidHostname error
 type invalidAppType error
@@ -0,0 +1,95 @@
+package ddevapp
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// ContainerEvent is a change in the state of one of a project's containers
+type ContainerEvent struct {
+	// Service is the compose service of the container, like "web"
+	Service string
+	// Container is the name of the container, like "ddev-d9-web"
+	Container string
+	// Action is "start", "stop", "die" or "health"
+	Action string
+	// Health is the new health status, like "healthy", of a "health" event
+	Health string
+	// Time is when docker reported the event
+	Time time.Time
+}
+
+// WatchEvents streams start, stop, die and health events of the project's
+// containers until ctx is canceled, when the channel is closed. It is also
+// closed if the connection to docker is lost.
+func (app *DdevApp) WatchEvents(ctx context.Context) (<-chan ContainerEvent, error) {
+	// Each docker client has its own event monitor, so the filter here
+	// doesn't affect anyone else watching events.
+	client := dockerutil.GetDockerClient()
+	listener := make(chan *docker.APIEvents, 10)
+	err := client.AddEventListenerWithOptions(docker.EventsOptions{
+		Filters: map[string][]string{
+			"type":  {"container"},
+			"label": {"com.ddev.site-name=" + app.GetName()},
+			"event": {"start", "stop", "die", "health_status"},
+		},
+	}, listener)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan ContainerEvent)
+	go func() {
+		defer close(events)
+		defer func() {
+			_ = client.RemoveEventListener(listener)
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-listener:
+				if !ok {
+					return
+				}
+				event, ok := app.containerEvent(e)
+				if !ok {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// containerEvent converts a docker event, returning false if it isn't
+// about one of the project's containers.
+func (app *DdevApp) containerEvent(e *docker.APIEvents) (ContainerEvent, bool) {
+	if e == nil || e.Type != "container" || e.Actor.Attributes["com.ddev.site-name"] != app.GetName() {
+		return ContainerEvent{}, false
+	}
+	event := ContainerEvent{
+		Service:   e.Actor.Attributes["com.docker.compose.service"],
+		Container: e.Actor.Attributes["name"],
+		Action:    e.Action,
+		Time:      time.Unix(0, e.TimeNano),
+	}
+	if health := strings.TrimPrefix(e.Action, "health_status: "); health != e.Action {
+		event.Action = "health"
+		event.Health = health
+	}
+	switch event.Action {
+	case "start", "stop", "die", "health":
+		return event, true
+	}
+	return ContainerEvent{}, false
+}
@@ -0,0 +1,65 @@
+package ddevapp
+
+import (
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// SetRemoveContainer replaces how Cleanup() removes containers, until the
+// returned func is called.
+func SetRemoveContainer(f func(opts docker.RemoveContainerOptions) error) func() {
+	orig := removeContainer
+	removeContainer = f
+	return func() {
+		removeContainer = orig
+	}
+}
+
+// SetWarmupHTTPClient replaces the client that fetches the warmup_urls,
+// until the returned func is called.
+func SetWarmupHTTPClient(c HTTPGetter) func() {
+	orig := warmupHTTPClient
+	warmupHTTPClient = c
+	return func() {
+		warmupHTTPClient = orig
+	}
+}
+
+// SetPullImage replaces how PullContainerImages pulls images, until the
+// returned func is called.
+func SetPullImage(f func(imageName string) error) func() {
+	orig := pullImage
+	pullImage = f
+	return func() {
+		pullImage = orig
+	}
+}
+
+// SetStopContainer replaces how Cleanup() stops containers, until the
+// returned func is called.
+func SetStopContainer(f func(id string, timeout uint) error) func() {
+	orig := stopContainer
+	stopContainer = f
+	return func() {
+		stopContainer = orig
+	}
+}
+
+// SetComposeDownCmd replaces how Cleanup() runs docker-compose down, until
+// the returned func is called.
+func SetComposeDownCmd(f func(composeFiles []string, action ...string) (string, string, error)) func() {
+	orig := composeDownCmd
+	composeDownCmd = f
+	return func() {
+		composeDownCmd = orig
+	}
+}
+
+// SetDockerAvailableSpace replaces how the free space on docker's disk is
+// found, until the returned func is called.
+func SetDockerAvailableSpace(f func() (int64, error)) func() {
+	orig := dockerAvailableSpace
+	dockerAvailableSpace = f
+	return func() {
+		dockerAvailableSpace = orig
+	}
+}
@@ -0,0 +1,67 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/drud/ddev/pkg/fileutil"
+	"github.com/drud/ddev/pkg/util"
+)
+
+// getFixturePath returns the absolute path of a fixture_db or fixture_files
+// setting, which may be relative to the project root.
+func (app *DdevApp) getFixturePath(fixture string) string {
+	if fixture == "" || filepath.IsAbs(fixture) {
+		return fixture
+	}
+	return filepath.Join(app.AppRoot, fixture)
+}
+
+// ResetToFixture puts the project back into the state described by
+// fixture_db and fixture_files: the db is dropped and re-imported from
+// fixture_db, and the upload directory is emptied and re-imported from
+// fixture_files. Either fixture may be omitted, but not both.
+func (app *DdevApp) ResetToFixture() error {
+	dbFixture := app.getFixturePath(app.FixtureDB)
+	filesFixture := app.getFixturePath(app.FixtureFiles)
+	if dbFixture == "" && filesFixture == "" {
+		return fmt.Errorf("neither fixture_db nor fixture_files is configured for project %s", app.Name)
+	}
+	for _, fixture := range []string{dbFixture, filesFixture} {
+		if fixture != "" && !fileutil.FileExists(fixture) {
+			return fmt.Errorf("fixture %s does not exist", fixture)
+		}
+	}
+
+	if dbFixture != "" {
+		// ForceImportDB drops the database before importing unless told not
+		// to, even if it was last imported from the fixture.
+		err := app.ForceImportDB(dbFixture, "", false, false, "db")
+		if err != nil {
+			return fmt.Errorf("failed to import db fixture %s: %v", dbFixture, err)
+		}
+	}
+
+	if filesFixture != "" {
+		destPath := app.GetHostUploadDirFullPath()
+		if destPath == "" {
+			return fmt.Errorf("upload_dir is not set for this project, so fixture_files can't be imported")
+		}
+		err := os.RemoveAll(destPath)
+		if err != nil {
+			return fmt.Errorf("failed to clear %s: %v", destPath, err)
+		}
+		err = os.MkdirAll(destPath, 0755)
+		if err != nil {
+			return err
+		}
+		err = app.ImportFiles(filesFixture, "")
+		if err != nil {
+			return fmt.Errorf("failed to import files fixture %s: %v", filesFixture, err)
+		}
+	}
+
+	util.Success("Project %s has been reset to its fixtures", app.Name)
+	return nil
+}
@@ -0,0 +1,59 @@
+package ddevapp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// withImageRegistry returns image as it's pulled from image_registry, like
+// myregistry.local/drud/ddev-webserver:v1.19.0 for drud/ddev-webserver:v1.19.0.
+// Images that already name a registry are left alone, as are all images if
+// image_registry isn't set.
+func (app *DdevApp) withImageRegistry(image string) string {
+	if app.ImageRegistry == "" || image == "" || imageHasRegistry(image) {
+		return image
+	}
+	return strings.TrimSuffix(app.ImageRegistry, "/") + "/" + image
+}
+
+// imageHasRegistry tells whether image starts with a registry host, like
+// myregistry.local:5000/ddev/web or localhost/web, rather than being an
+// image on Docker Hub.
+func imageHasRegistry(image string) bool {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) < 2 {
+		return false
+	}
+	return strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost"
+}
+
+// GetWebImageFromRegistry returns the web image the project uses, from
+// image_registry if it's set.
+func (app *DdevApp) GetWebImageFromRegistry() string {
+	return app.withImageRegistry(app.WebImage)
+}
+
+// GetDBImageFromRegistry returns the db image the project uses, from
+// image_registry if it's set.
+func (app *DdevApp) GetDBImageFromRegistry() string {
+	return app.withImageRegistry(app.GetDBImage())
+}
+
+// GetDBAImageFromRegistry returns the dba image the project uses, from
+// image_registry if it's set.
+func (app *DdevApp) GetDBAImageFromRegistry() string {
+	return app.withImageRegistry(app.DBAImage)
+}
+
+// validateImageRegistry checks image_registry, which must be a registry
+// host, optionally followed by a path, like myregistry.local:5000/mirror.
+func (app *DdevApp) validateImageRegistry() error {
+	if app.ImageRegistry == "" {
+		return nil
+	}
+	registry := strings.TrimSuffix(app.ImageRegistry, "/")
+	if strings.ContainsAny(registry, " \t\"'@") || strings.Contains(registry, "://") || !imageHasRegistry(registry+"/image") {
+		return fmt.Errorf("invalid image_registry %q: it must be a registry host, optionally with a port and path, like myregistry.local:5000/mirror", app.ImageRegistry)
+	}
+	return nil
+}
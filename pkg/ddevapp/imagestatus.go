@@ -0,0 +1,82 @@
+package ddevapp
+
+import (
+	"fmt"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+	"github.com/drud/ddev/pkg/nodeps"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// ImageInfo describes whether a service's container runs the image the
+// project is configured with, as it is available locally now.
+type ImageInfo struct {
+	// Image is the configured image, like drud/ddev-webserver:v1.19.0
+	Image string
+	// ImageID is the ID Image refers to locally, "" if it hasn't been pulled
+	ImageID string
+	// ContainerImageID is the ID of the image the container was created from
+	ContainerImageID string
+	// UpToDate is false if the container was created before Image was
+	// updated, so it needs to be recreated with a restart
+	UpToDate bool
+}
+
+// ImageStatus reports for the running web and db containers whether they
+// use the latest local version of their configured images. Containers that
+// aren't there are left out.
+func (app *DdevApp) ImageStatus() (map[string]ImageInfo, error) {
+	images := map[string]string{"web": app.GetWebImageFromRegistry()}
+	if !nodeps.ArrayContainsString(app.GetOmittedContainers(), "db") {
+		images["db"] = app.GetDBImageFromRegistry()
+	}
+
+	client := dockerutil.GetDockerClient()
+	status := map[string]ImageInfo{}
+	for service, image := range images {
+		container, err := app.FindContainerByType(service)
+		if err != nil {
+			return nil, err
+		}
+		if container == nil {
+			continue
+		}
+		inspect, err := client.InspectContainerWithOptions(docker.InspectContainerOptions{ID: container.ID})
+		if err != nil {
+			return nil, fmt.Errorf("unable to inspect %s container: %v", service, err)
+		}
+		info := ImageInfo{Image: image, ContainerImageID: inspect.Image}
+
+		// The containers run images built on top of the configured one,
+		// so they are up to date if they start with its layers.
+		configured, err := client.InspectImage(image)
+		if err != nil {
+			status[service] = info
+			continue
+		}
+		info.ImageID = configured.ID
+		running, err := client.InspectImage(inspect.Image)
+		if err != nil {
+			return nil, fmt.Errorf("unable to inspect image %s of %s container: %v", inspect.Image, service, err)
+		}
+		if running.RootFS != nil && configured.RootFS != nil {
+			info.UpToDate = hasLayerPrefix(running.RootFS.Layers, configured.RootFS.Layers)
+		}
+		status[service] = info
+	}
+	return status, nil
+}
+
+// hasLayerPrefix tells whether an image with layers is based on an image
+// with baseLayers.
+func hasLayerPrefix(layers []string, baseLayers []string) bool {
+	if len(baseLayers) == 0 || len(baseLayers) > len(layers) {
+		return false
+	}
+	for i := range baseLayers {
+		if layers[i] != baseLayers[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Phases of a database import reported by ImportDBEvents()
+const (
+	// ImportPhaseDownload is reading and checking the dump. Dumps are
+	// local files, so there's nothing to fetch.
+	ImportPhaseDownload = "download"
+	// ImportPhaseExtract is extracting the dump from its archive, or
+	// copying it if it isn't one
+	ImportPhaseExtract = "extract"
+	// ImportPhaseLoad is loading the dump into the database
+	ImportPhaseLoad = "load"
+	// ImportPhasePostProcess is writing the settings file and running
+	// the project type's post-import action
+	ImportPhasePostProcess = "post-process"
+	// ImportPhaseDone is the last event of an import, whether it
+	// succeeded or not
+	ImportPhaseDone = "done"
+)
+
+// ImportEvent is sent by ImportDBEvents() when an import reaches a phase
+type ImportEvent struct {
+	// Phase is one of the ImportPhase* constants
+	Phase string
+	// Time is when the phase started
+	Time time.Time
+	// Err is why the import failed, only set on the ImportPhaseDone event
+	Err error
+}
+
+// ImportDBEvents starts importing the dump at path into the db database like
+// ImportDB() does, and returns a channel of the phases it goes through.
+// The last event has ImportPhaseDone and the import's error, if any, after
+// which the channel is closed.
+func (app *DdevApp) ImportDBEvents(path string) (<-chan ImportEvent, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("unable to import %s: %v", path, err)
+	}
+
+	// There's room for every event, so a slow reader doesn't hold up the
+	// import.
+	events := make(chan ImportEvent, 5)
+	go func() {
+		defer close(events)
+		err := app.importDB(path, "", false, false, "db", true, false, nil, func(phase string) {
+			events <- ImportEvent{Phase: phase, Time: time.Now()}
+		})
+		events <- ImportEvent{Phase: ImportPhaseDone, Time: time.Now(), Err: err}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,257 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/drud/ddev/pkg/archive"
+	"github.com/drud/ddev/pkg/fileutil"
+	"github.com/drud/ddev/pkg/util"
+)
+
+// FilesImport is one of the directories or archives ImportFilesMultiple
+// imports.
+type FilesImport struct {
+	// Source is a directory or a .tar, .tar.gz, .tgz or .zip archive
+	Source string
+	// ExtractPath is the path in the archive to import, "" for all of it
+	ExtractPath string
+	// Target is the directory to import into, relative to the project root.
+	// If it's "", the files go into the upload dir, like with ImportFiles().
+	Target string
+}
+
+// ImportFilesMultiple imports several directories or archives, like public
+// and private files, each into its own target. The targets are replaced
+// by the imported files. Everything is checked before anything is
+// imported, and the import hooks run once.
+func (app *DdevApp) ImportFilesMultiple(imports []FilesImport) error {
+	if len(imports) == 0 {
+		return fmt.Errorf("no files to import")
+	}
+	targets := map[string]bool{}
+	for _, imp := range imports {
+		if err := validateImportSource(imp.Source, "files"); err != nil {
+			return err
+		}
+		target := filepath.Clean(imp.Target)
+		if imp.Target != "" && (filepath.IsAbs(imp.Target) || target == "." || target == ".." || strings.HasPrefix(target, ".."+string(filepath.Separator))) {
+			return fmt.Errorf("invalid target %s for %s: it must be a directory inside the project, relative to its root", imp.Target, imp.Source)
+		}
+		if imp.Target != "" {
+			if err := app.checkFilesImportTarget(target); err != nil {
+				return fmt.Errorf("invalid target %s for %s: %v", imp.Target, imp.Source, err)
+			}
+		}
+		if targets[target] {
+			return fmt.Errorf("more than one import into %s", imp.Target)
+		}
+		targets[target] = true
+	}
+	app.DockerEnv()
+
+	if err := app.ProcessHooks("pre-import-files"); err != nil {
+		return err
+	}
+
+	for _, imp := range imports {
+		var err error
+		if imp.Target == "" {
+			err = app.ImportFilesAction(imp.Source, imp.ExtractPath)
+		} else {
+			destPath := filepath.Join(app.AppRoot, imp.Target)
+			util.Success("Importing files from %s into %s", imp.Source, destPath)
+			err = importFilesToPath(imp.Source, imp.ExtractPath, destPath)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %v", imp.Source, err)
+		}
+	}
+
+	if app.SiteStatus() == SiteRunning {
+		for _, imp := range imports {
+			var err error
+			if imp.Target == "" {
+				err = app.FixFilePermissions()
+			} else {
+				err = app.fixFileOwnership(path.Join("/var/www/html", filepath.ToSlash(filepath.Clean(imp.Target))))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	//nolint: revive
+	if err := app.ProcessHooks("post-import-files"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkFilesImportTarget returns an error if importing into target, which is
+// relative to the project root, would replace the project root, the docroot
+// or the .ddev directory, or anything in .ddev.
+func (app *DdevApp) checkFilesImportTarget(target string) error {
+	destPath := filepath.Join(app.AppRoot, target)
+	protected := map[string]string{
+		app.AppRoot:                             "the project root",
+		filepath.Join(app.AppRoot, app.Docroot): "the docroot",
+		app.AppConfDir():                        "the .ddev directory",
+	}
+	for dir, name := range protected {
+		if rel, err := filepath.Rel(destPath, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("it would replace %s", name)
+		}
+	}
+	if rel, err := filepath.Rel(app.AppConfDir(), destPath); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("it is in the .ddev directory")
+	}
+	return nil
+}
+
+// importFilesToPath replaces destPath with the files in the directory or
+// archive at importPath.
+func importFilesToPath(importPath, extPath, destPath string) error {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+
+	// If the destination path exists, remove it as was warned
+	if fileutil.FileExists(destPath) {
+		if err := os.RemoveAll(destPath); err != nil {
+			return fmt.Errorf("failed to cleanup %s before import: %v", destPath, err)
+		}
+	}
+
+	if isTar(importPath) {
+		if err := archive.Untar(importPath, destPath, extPath); err != nil {
+			return fmt.Errorf("failed to extract provided archive: %v", err)
+		}
+		return nil
+	}
+
+	if isZip(importPath) {
+		if err := archive.Unzip(importPath, destPath, extPath); err != nil {
+			return fmt.Errorf("failed to extract provided archive: %v", err)
+		}
+		return nil
+	}
+
+	return fileutil.CopyDir(importPath, destPath)
+}
+
+// extractStripped extracts extPath of the archive at importPath into a
+// temporary directory, dropping the first n directories of every path in
+// it. Files that aren't at least n+1 deep are left out. The returned
+// cleanup function removes the directory.
+func extractStripped(importPath, extPath string, n int) (string, func(), error) {
+	tmpDir, err := os.MkdirTemp("", "ddev-import-files")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(tmpDir)
+	}
+	extracted := filepath.Join(tmpDir, "extracted")
+	stripped := filepath.Join(tmpDir, "stripped")
+	if isTar(importPath) {
+		err = archive.Untar(importPath, extracted, extPath)
+	} else {
+		err = archive.Unzip(importPath, extracted, extPath)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to extract provided archive: %v", err)
+	}
+	if err = os.MkdirAll(stripped, 0755); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	found := false
+	err = filepath.Walk(extracted, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(extracted, p)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if rel == "." || len(parts) <= n {
+			return nil
+		}
+		target := filepath.Join(stripped, filepath.Join(parts[n:]...))
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		found = true
+		return os.Rename(p, target)
+	})
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to strip %d path components from %s: %v", n, importPath, err)
+	}
+	if !found {
+		cleanup()
+		return "", nil, fmt.Errorf("no files are left in %s after stripping %d path components", importPath, n)
+	}
+	return stripped, cleanup, nil
+}
+
+var filesOwnerRegex = regexp.MustCompile(`^[a-z0-9_][a-z0-9_.-]*(:[a-z0-9_][a-z0-9_.-]*)?$`)
+
+// GetImportFilesOwner returns the owner imported files are given in the web
+// container, import_files_owner or the uid:gid the web server runs as.
+func (app *DdevApp) GetImportFilesOwner() string {
+	if app.ImportFilesOwner != "" {
+		return app.ImportFilesOwner
+	}
+	uid, gid, _ := util.GetContainerUIDGid()
+	return uid + ":" + gid
+}
+
+// validateImportFilesOwner checks that import_files_owner is user or
+// user:group.
+func (app *DdevApp) validateImportFilesOwner() error {
+	if app.ImportFilesOwner != "" && !filesOwnerRegex.MatchString(app.ImportFilesOwner) {
+		return fmt.Errorf("invalid import_files_owner %q: it must be a user or user:group, like www-data:www-data or 1000:1000", app.ImportFilesOwner)
+	}
+	return nil
+}
+
+// FixFilePermissions gives the files in the project's upload directory to
+// the web server user in the web container, or to import_files_owner.
+// Files extracted from an archive can end up owned by another uid, which
+// the web server can't write to. The project must be running.
+func (app *DdevApp) FixFilePermissions() error {
+	uploadDir := app.GetContainerUploadDirFullPath()
+	if uploadDir == "" {
+		return nil
+	}
+	return app.fixFileOwnership(uploadDir)
+}
+
+// fixFileOwnership gives containerPath and everything in it to
+// GetImportFilesOwner() in the web container.
+func (app *DdevApp) fixFileOwnership(containerPath string) error {
+	owner := app.GetImportFilesOwner()
+	quotedPath := "'" + strings.ReplaceAll(containerPath, "'", `'\''`) + "'"
+	quotedOwner := "'" + strings.ReplaceAll(owner, "'", `'\''`) + "'"
+	_, stderr, err := app.Exec(&ExecOpts{
+		Cmd: fmt.Sprintf("if [ -e %s ]; then sudo chown -R %s %s; fi", quotedPath, quotedOwner, quotedPath),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change the owner of %s in the web container to %s: %v, stderr=%s", containerPath, owner, err, stderr)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package ddevapp
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/drud/ddev/pkg/util"
+)
+
+// dbImportMarker returns the file in the db container that records the
+// hash of the dump targetDB was last imported from. It's in the data
+// volume, so it goes away with the database.
+func dbImportMarker(targetDB string) string {
+	return "/var/lib/mysql/.ddev-import-" + targetDB
+}
+
+// importSourceHash returns a hash of the dump at imPath and the path
+// extracted from it.
+func importSourceHash(imPath string, extPath string) (string, error) {
+	f, err := os.Open(imPath)
+	if err != nil {
+		return "", err
+	}
+	defer util.CheckClose(f)
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	_, _ = h.Write([]byte("\x00" + extPath))
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// IsDBImportUnchanged reports whether targetDB was last imported from the
+// same dump, so that ImportDB() would skip importing it again.
+func (app *DdevApp) IsDBImportUnchanged(imPath string, extPath string, targetDB string) (bool, error) {
+	if imPath == "" {
+		return false, nil
+	}
+	if targetDB == "" {
+		targetDB = "db"
+	}
+	hash, err := importSourceHash(imPath, extPath)
+	if err != nil {
+		return false, err
+	}
+	return app.lastImportHash(targetDB) == hash, nil
+}
+
+// lastImportHash returns the hash recorded by the last import into
+// targetDB, or "" if there is none or the db container can't be reached.
+func (app *DdevApp) lastImportHash(targetDB string) string {
+	stdout, _, err := app.Exec(&ExecOpts{
+		Service: "db",
+		Cmd:     fmt.Sprintf("cat %s 2>/dev/null || true", dbImportMarker(targetDB)),
+	})
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(stdout)
+}
@@ -0,0 +1,121 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/drud/ddev/pkg/util"
+)
+
+// States of an ImportProgress
+const (
+	ImportRunning  = "running"
+	ImportComplete = "complete"
+	ImportFailed   = "failed"
+)
+
+// ImportProgress is the status of a database import started with
+// ImportDBAsync().
+type ImportProgress struct {
+	// Path is the dump being imported
+	Path string
+	// State is ImportRunning, ImportComplete or ImportFailed
+	State string
+	// Started is when the import began
+	Started time.Time
+	// Finished is when the import ended, zero while it's running
+	Finished time.Time
+	// Err is why the import failed
+	Err error
+	// BytesDone is how much of the dump has been imported so far
+	BytesDone int64
+	// BytesTotal is the size of the dump
+	BytesTotal int64
+}
+
+// Percent returns how much of the dump has been imported, from 0 to 100
+func (p ImportProgress) Percent() float64 {
+	if p.BytesTotal <= 0 {
+		return 0
+	}
+	return float64(p.BytesDone) / float64(p.BytesTotal) * 100
+}
+
+// Elapsed returns how long the import has been running or took
+func (p ImportProgress) Elapsed() time.Duration {
+	if p.Finished.IsZero() {
+		return time.Since(p.Started)
+	}
+	return p.Finished.Sub(p.Started)
+}
+
+// importJobs are the imports started with ImportDBAsync() that are running,
+// or finished but whose final status hasn't been read yet
+var importJobs = struct {
+	sync.Mutex
+	jobs map[string]*ImportProgress
+}{jobs: map[string]*ImportProgress{}}
+
+// finishedImportJobTTL is how long a finished import job is kept if its
+// final status is never read
+const finishedImportJobTTL = 10 * time.Minute
+
+// ImportDBAsync starts importing the dump at path into the db database like
+// ImportDB() does, but in the background. The returned job ID can be passed
+// to ImportStatus() to find out when the import is done.
+func (app *DdevApp) ImportDBAsync(path string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("unable to import %s: %v", path, err)
+	}
+
+	jobID := app.Name + "-" + util.RandString(12)
+	progress := &ImportProgress{
+		Path:    path,
+		State:   ImportRunning,
+		Started: time.Now(),
+	}
+	importJobs.Lock()
+	importJobs.jobs[jobID] = progress
+	importJobs.Unlock()
+
+	go func() {
+		err := app.ImportDBWithProgress(path, "", false, "db", func(done int64, total int64) {
+			importJobs.Lock()
+			defer importJobs.Unlock()
+			progress.BytesDone, progress.BytesTotal = done, total
+		})
+		importJobs.Lock()
+		defer importJobs.Unlock()
+		progress.Finished = time.Now()
+		progress.State = ImportComplete
+		if err != nil {
+			progress.State = ImportFailed
+			progress.Err = err
+		}
+		time.AfterFunc(finishedImportJobTTL, func() {
+			importJobs.Lock()
+			defer importJobs.Unlock()
+			delete(importJobs.jobs, jobID)
+		})
+	}()
+
+	return jobID, nil
+}
+
+// ImportStatus returns the progress of an import started with
+// ImportDBAsync(). Once the import has finished its job is removed when the
+// final status is read, or after finishedImportJobTTL if it never is.
+func (app *DdevApp) ImportStatus(jobID string) (ImportProgress, error) {
+	importJobs.Lock()
+	defer importJobs.Unlock()
+	progress, ok := importJobs.jobs[jobID]
+	if !ok {
+		return ImportProgress{}, fmt.Errorf("no import job %s", jobID)
+	}
+	if progress.State != ImportRunning {
+		delete(importJobs.jobs, jobID)
+	}
+	return *progress, nil
+}
@@ -0,0 +1,74 @@
+package ddevapp
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ImportReporter is called while a database dump is imported with the
+// number of bytes of the dump file processed so far and its size. The
+// last call has bytesDone == bytesTotal.
+type ImportReporter func(bytesDone int64, bytesTotal int64)
+
+// ImportDBWithProgress is like ImportDB, but it reports the progress of the
+// import to report as it goes. For compressed dumps the bytes processed are
+// estimated from how much of the extracted dump has been imported.
+func (app *DdevApp) ImportDBWithProgress(imPath string, extPath string, noDrop bool, targetDB string, report ImportReporter) error {
+	return app.importDB(imPath, extPath, false, noDrop, targetDB, true, false, report, nil)
+}
+
+// importPVCommand is how the import reads the extracted dump. When the
+// progress is reported, pv prints the number of bytes it has read to stderr
+// every half second.
+func importPVCommand(report ImportReporter) string {
+	if report == nil {
+		return "pv"
+	}
+	return "pv -f -n -b -i 0.5"
+}
+
+// execReportingImportProgress runs the import in opts, passing the byte
+// counts pv prints to report, scaled from the extractedSize of the dump
+// to the totalSize of the file it came from. The rest of stderr is returned.
+func (app *DdevApp) execReportingImportProgress(opts *ExecOpts, totalSize int64, extractedSize int64, report ImportReporter) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	opts.NoCapture = true
+	opts.Tty = false
+	opts.Stderr = w
+
+	var stderr strings.Builder
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := scanner.Text()
+			read, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+			if err != nil {
+				stderr.WriteString(line + "\n")
+				continue
+			}
+			done := totalSize
+			if extractedSize > 0 && read < extractedSize {
+				done = int64(float64(read) / float64(extractedSize) * float64(totalSize))
+			}
+			report(done, totalSize)
+		}
+	}()
+
+	_, _, err = app.Exec(opts)
+	_ = w.Close()
+	wg.Wait()
+	_ = r.Close()
+	if err == nil {
+		report(totalSize, totalSize)
+	}
+	return stderr.String(), err
+}
@@ -46,6 +46,10 @@ func createMagentoSettingsFile(app *DdevApp) (string, error) {
 	} else {
 		output.UserOut.Printf("No %s file exists, creating one", app.SiteSettingsPath)
 
+		// app/etc doesn't exist yet in a fresh checkout
+		if err := os.MkdirAll(filepath.Dir(app.SiteSettingsPath), 0755); err != nil {
+			return "", err
+		}
 		content, err := bundledAssets.ReadFile("magento/local.xml")
 		if err != nil {
 			return "", err
@@ -146,6 +150,10 @@ func createMagento2SettingsFile(app *DdevApp) (string, error) {
 	} else {
 		output.UserOut.Printf("No %s file exists, creating one", app.SiteSettingsPath)
 
+		// app/etc doesn't exist yet in a fresh checkout
+		if err := os.MkdirAll(filepath.Dir(app.SiteSettingsPath), 0755); err != nil {
+			return "", err
+		}
 		content, err := bundledAssets.ReadFile("magento/env.php")
 		if err != nil {
 			return "", err
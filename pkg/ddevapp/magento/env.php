@@ -1,6 +1,6 @@
 <?php
 
-// #ddev-generated: Automatically generated ddev local.xml
+// #ddev-generated: Automatically generated ddev env.php
 // Remove the line above or this whole comment if you want
 // ddev to ignore this file and not regenerate it.
 
@@ -0,0 +1,183 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/drud/ddev/pkg/fileutil"
+	"github.com/drud/ddev/pkg/nodeps"
+	"github.com/drud/ddev/pkg/util"
+)
+
+// Multisite is one of the extra sites of a Drupal multisite project.
+// Each has its own directory in sites/, hostname and database.
+type Multisite struct {
+	// Name is the directory of the site in sites/, like sites/<name>
+	Name string `yaml:"name"`
+	// Hostname is handled like an additional_hostnames entry, so it gets
+	// the project_tld appended. It defaults to <name>.<project name>.
+	Hostname string `yaml:"hostname,omitempty"`
+	// Database is the name of the site's database, defaulting to its name
+	Database string `yaml:"database,omitempty"`
+}
+
+var multisiteNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+var multisiteDatabaseRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// GetMultisites returns the project's multisites with the defaults for
+// hostname and database filled in.
+func (app *DdevApp) GetMultisites() []Multisite {
+	sites := []Multisite{}
+	for _, site := range app.Multisites {
+		if site.Hostname == "" {
+			site.Hostname = site.Name + "." + app.Name
+		}
+		site.Hostname = strings.ToLower(site.Hostname)
+		if site.Database == "" {
+			site.Database = strings.NewReplacer(".", "_", "-", "_").Replace(site.Name)
+		}
+		sites = append(sites, site)
+	}
+	return sites
+}
+
+// MultisiteHostname returns the full hostname a multisite is served on
+func (app *DdevApp) MultisiteHostname(site Multisite) string {
+	return site.Hostname + "." + app.ProjectTLD
+}
+
+// MultisiteURL returns the URL of a multisite, using https if the
+// project's primary URL does.
+func (app *DdevApp) MultisiteURL(site Multisite) string {
+	primaryURL := app.GetPrimaryURL()
+	return strings.Replace(primaryURL, app.GetHostname(), app.MultisiteHostname(site), 1)
+}
+
+// validateMultisites checks that multisites are only used with Drupal
+// types that support sites.php and that their settings are usable.
+func (app *DdevApp) validateMultisites() error {
+	if len(app.Multisites) == 0 {
+		return nil
+	}
+	switch app.Type {
+	case nodeps.AppTypeDrupal7, nodeps.AppTypeDrupal8, nodeps.AppTypeDrupal9, nodeps.AppTypeDrupal10:
+	default:
+		return fmt.Errorf("multisites are only supported for drupal7 and later project types, not %s", app.Type)
+	}
+
+	names := map[string]bool{}
+	databases := map[string]bool{"db": true}
+	for _, site := range app.GetMultisites() {
+		if !multisiteNameRegex.MatchString(site.Name) || site.Name == "default" {
+			return fmt.Errorf("invalid multisite name '%s': it must be a directory name in sites/ other than 'default'", site.Name)
+		}
+		if names[site.Name] {
+			return fmt.Errorf("multisite '%s' is configured more than once", site.Name)
+		}
+		names[site.Name] = true
+		if !hostRegex.MatchString(app.MultisiteHostname(site)) {
+			return fmt.Errorf("invalid hostname '%s' for multisite '%s'", site.Hostname, site.Name)
+		}
+		if !multisiteDatabaseRegex.MatchString(site.Database) {
+			return fmt.Errorf("invalid database '%s' for multisite '%s': only letters, digits and _ are allowed", site.Database, site.Name)
+		}
+		if databases[site.Database] {
+			return fmt.Errorf("database '%s' of multisite '%s' is already used by another site", site.Database, site.Name)
+		}
+		databases[site.Database] = true
+	}
+	return nil
+}
+
+// CreateMultisiteDatabases creates the database of each multisite if it
+// doesn't exist yet and lets the db user use it.
+func (app *DdevApp) CreateMultisiteDatabases() error {
+	if len(app.Multisites) == 0 || nodeps.ArrayContainsString(app.GetOmittedContainers(), "db") {
+		return nil
+	}
+	statements := []string{}
+	for _, site := range app.GetMultisites() {
+		statements = append(statements, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s; GRANT ALL ON %s.* TO 'db'@'%%';", site.Database, site.Database))
+	}
+	_, stderr, err := app.Exec(&ExecOpts{
+		Service: "db",
+		Cmd:     fmt.Sprintf(`mysql -uroot -proot -e "%s"`, strings.Join(statements, " ")),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create multisite databases: %v, stderr=%s", err, stderr)
+	}
+	return nil
+}
+
+// writeDrupalMultisiteSettings writes settings.php and settings.ddev.php
+// for each multisite and a sites.php routing their hostnames to them.
+func writeDrupalMultisiteSettings(app *DdevApp) error {
+	if len(app.Multisites) == 0 {
+		return nil
+	}
+	sitesDir := filepath.Join(app.AppRoot, app.Docroot, "sites")
+
+	for _, site := range app.GetMultisites() {
+		drupalConfig := NewDrupalSettings(app)
+		drupalConfig.DatabaseName = site.Database
+		drupalConfig.SitePath = path.Join("sites", site.Name)
+
+		settingsPath := filepath.Join(sitesDir, site.Name, drupalConfig.SiteSettings)
+		if !fileutil.FileExists(settingsPath) {
+			if err := writeDrupalSettingsPHP(settingsPath, app.Type); err != nil {
+				return fmt.Errorf("failed to write %s: %v", settingsPath, err)
+			}
+		}
+		included, err := settingsHasInclude(drupalConfig, settingsPath)
+		if err != nil {
+			return fmt.Errorf("failed to check for include: %v", err)
+		}
+		if !included {
+			if err := appendIncludeToDrupalSettingsFile(settingsPath, app.Type); err != nil {
+				return fmt.Errorf("failed to include %s in %s: %v", drupalConfig.SiteSettingsDdev, settingsPath, err)
+			}
+		}
+
+		ddevSettingsPath := filepath.Join(sitesDir, site.Name, drupalConfig.SiteSettingsDdev)
+		if err := writeDrupalSettingsDdevPhp(drupalConfig, ddevSettingsPath, app); err != nil {
+			return fmt.Errorf("failed to write Drupal settings file %s: %v", ddevSettingsPath, err)
+		}
+	}
+
+	return writeDrupalSitesPHP(app, filepath.Join(sitesDir, "sites.php"))
+}
+
+// writeDrupalSitesPHP writes a sites.php mapping the hostname of each
+// multisite to its directory, unless the user manages sites.php.
+func writeDrupalSitesPHP(app *DdevApp, filePath string) error {
+	if fileutil.FileExists(filePath) {
+		signatureFound, err := fileutil.FgrepStringInFile(filePath, DdevFileSignature)
+		if err != nil {
+			return err
+		}
+		if !signatureFound {
+			util.Warning("%s already exists and is managed by the user, make sure it maps the multisite hostnames.", filepath.Base(filePath))
+			return nil
+		}
+	}
+
+	contents := `<?php
+
+/**
+ * @file
+ * ` + DdevFileSignature + `: Automatically generated sites.php file for the multisites in .ddev/config.yaml
+ * ddev manages this file and may delete or overwrite the file unless this comment is removed.
+ * Remove this comment if you don't want ddev to manage this file.
+ */
+
+`
+	for _, site := range app.GetMultisites() {
+		contents += fmt.Sprintf("$sites['%s'] = '%s';\n", app.MultisiteHostname(site), site.Name)
+	}
+
+	return os.WriteFile(filePath, []byte(contents), 0644)
+}
@@ -483,9 +483,10 @@ func IsMutagenVolumeMounted(app *DdevApp) (bool, error) {
 	return false, nil
 }
 
-// IsMutagenEnabled returns true if mutagen is enabled locally or globally
+// IsMutagenEnabled returns true if mutagen is enabled locally or globally,
+// or with mount_type: sync.
 // It's also required and set if NoBindMounts is set, since we have to have a way
 // to get code on there.
 func (app *DdevApp) IsMutagenEnabled() bool {
-	return app.MutagenEnabled || app.MutagenEnabledGlobal || globalconfig.DdevGlobalConfig.NoBindMounts
+	return app.MutagenEnabled || app.MountType == MountTypeSync || app.MutagenEnabledGlobal || globalconfig.DdevGlobalConfig.NoBindMounts
 }
@@ -1,9 +1,14 @@
 package ddevapp_test
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/drud/ddev/pkg/ddevapp"
+	"github.com/drud/ddev/pkg/dockerutil"
 	"github.com/drud/ddev/pkg/fileutil"
 	"github.com/drud/ddev/pkg/testcommon"
+	"github.com/drud/ddev/pkg/util"
 	asrt "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"os"
@@ -80,3 +85,66 @@ func TestNetworkAmbiguity(t *testing.T) {
 	}
 
 }
+
+// TestPruneNetworks checks that the network_name network of a removed
+// project is pruned, but not while the project is paused, and ddev_default
+// never is
+func TestPruneNetworks(t *testing.T) {
+	assert := asrt.New(t)
+	app := &ddevapp.DdevApp{}
+	netName := "ddev_test_prune_network"
+
+	site := TestSites[0]
+	switchDir := site.Chdir()
+	defer switchDir()
+
+	runTime := util.TimeTrack(time.Now(), fmt.Sprintf("%s %s", site.Name, t.Name()))
+	defer runTime()
+
+	testcommon.ClearDockerEnv()
+	err := app.Init(site.Dir)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		app.DockerNetworkName = ""
+		err = app.WriteConfig()
+		assert.NoError(err)
+		err = app.Start()
+		assert.NoError(err)
+		_ = dockerutil.RemoveNetwork(netName)
+	})
+
+	err = app.Stop(true, false)
+	require.NoError(t, err)
+	app.DockerNetworkName = netName
+	err = app.WriteConfig()
+	require.NoError(t, err)
+	err = app.Start()
+	require.NoError(t, err)
+
+	orphans, err := ddevapp.OrphanedNetworks()
+	require.NoError(t, err)
+	assert.NotContains(orphans, netName)
+
+	// The stopped containers of a paused project still use the network
+	err = app.Pause()
+	require.NoError(t, err)
+	orphans, err = ddevapp.OrphanedNetworks()
+	require.NoError(t, err)
+	assert.NotContains(orphans, netName)
+	assert.NotContains(orphans, app.ProjectName()+"_default")
+
+	err = app.Stop(true, false)
+	require.NoError(t, err)
+	require.True(t, dockerutil.NetworkExists(netName))
+
+	orphans, err = ddevapp.OrphanedNetworks()
+	require.NoError(t, err)
+	assert.Contains(orphans, netName)
+	assert.NotContains(orphans, dockerutil.NetName)
+
+	err = ddevapp.PruneNetworks()
+	require.NoError(t, err)
+	assert.False(dockerutil.NetworkExists(netName))
+	assert.True(dockerutil.NetworkExists(dockerutil.NetName))
+}
@@ -0,0 +1,114 @@
+package ddevapp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+	"github.com/drud/ddev/pkg/globalconfig"
+	"github.com/drud/ddev/pkg/output"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// isDdevNetwork tells whether ddev created a network: ddev_default and
+// network_name networks are labeled, and docker-compose labels the default
+// network of each project with the "ddev-<project>" compose project.
+func isDdevNetwork(n docker.Network) bool {
+	return n.Name == dockerutil.NetName || n.Labels["com.ddev.platform"] == "ddev" || strings.HasPrefix(n.Labels["com.docker.compose.project"], "ddev-")
+}
+
+// OrphanedNetworks returns the names of the docker networks created by ddev
+// that no container uses any more, running or not. A network only
+// ddev-router is connected to counts as unused. ddev_default is never included while there are
+// ddev projects.
+func OrphanedNetworks() ([]string, error) {
+	orphans, err := findOrphanedNetworks()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, n := range orphans {
+		names = append(names, n.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// PruneNetworks removes the networks OrphanedNetworks() finds,
+// disconnecting ddev-router from them first.
+func PruneNetworks() error {
+	orphans, err := findOrphanedNetworks()
+	if err != nil {
+		return err
+	}
+	router, _ := FindDdevRouter()
+	client := dockerutil.GetDockerClient()
+
+	failed := []string{}
+	for _, n := range orphans {
+		if router != nil && router.Networks.Networks != nil {
+			if _, ok := router.Networks.Networks[n.Name]; ok {
+				err = client.DisconnectNetwork(n.ID, docker.NetworkConnectionOptions{Container: router.ID, Force: true})
+				if err != nil {
+					failed = append(failed, fmt.Sprintf("%s (%v)", n.Name, err))
+					continue
+				}
+			}
+		}
+		err = client.RemoveNetwork(n.ID)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", n.Name, err))
+			continue
+		}
+		output.UserOut.Printf("Removed unused network %s", n.Name)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to remove networks: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// findOrphanedNetworks does the work of OrphanedNetworks(), returning the
+// listed networks.
+func findOrphanedNetworks() ([]docker.Network, error) {
+	client := dockerutil.GetDockerClient()
+	networks, err := client.ListNetworks()
+	if err != nil {
+		return nil, err
+	}
+	// Stopped containers still use their networks, they're connected again
+	// when they start.
+	containers, err := client.ListContainers(docker.ListContainersOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	usedNetworks := map[string]bool{}
+	composeProjects := map[string]bool{}
+	for _, c := range containers {
+		if c.Labels["com.docker.compose.service"] == RouterProjectName {
+			continue
+		}
+		if project := c.Labels["com.docker.compose.project"]; project != "" {
+			composeProjects[project] = true
+		}
+		if c.Networks.Networks != nil {
+			for _, cn := range c.Networks.Networks {
+				usedNetworks[cn.NetworkID] = true
+			}
+		}
+	}
+	keepDefault := len(globalconfig.GetGlobalProjectList()) > 0
+
+	orphans := []docker.Network{}
+	for _, n := range networks {
+		if !isDdevNetwork(n) || (n.Name == dockerutil.NetName && keepDefault) {
+			continue
+		}
+		if usedNetworks[n.ID] || composeProjects[n.Labels["com.docker.compose.project"]] {
+			continue
+		}
+		orphans = append(orphans, n)
+	}
+	return orphans, nil
+}
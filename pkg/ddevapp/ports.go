@@ -17,11 +17,19 @@ var dbPort = "3306"
 // webPort defines the internal web port
 var webPort = "80"
 
+// solrPort defines the internal solr port
+var solrPort = "8983"
+
+// redisPort defines the internal redis port
+var redisPort = "6379"
+
 var ports = map[string]string{
 	"mailhog": mailhogPort,
 	"dba":     dbaPort,
 	"db":      dbPort,
 	"web":     webPort,
+	"solr":    solrPort,
+	"redis":   redisPort,
 }
 
 // GetPort returns the external router port (as a string) for the given service.
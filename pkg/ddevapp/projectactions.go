@@ -0,0 +1,70 @@
+package ddevapp
+
+import (
+	"fmt"
+)
+
+// LoadProject returns the initialized project in dir, or in the closest
+// directory above it with a .ddev/config.yaml, like ddev commands run
+// in dir would use.
+func LoadProject(dir string) (*DdevApp, error) {
+	appRoot, err := CheckForConf(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not find a project in %s. Have you run 'ddev config'?: %v", dir, err)
+	}
+	app := &DdevApp{}
+	if err = app.Init(appRoot); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// StartProject loads the project in dir and starts it.
+func StartProject(dir string) (*DdevApp, error) {
+	app, err := LoadProject(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = app.Start(); err != nil {
+		return app, fmt.Errorf("failed to start project %s: %v", app.Name, err)
+	}
+	return app, nil
+}
+
+// StopProject loads the project in dir and stops it, removing its
+// containers but keeping its database.
+func StopProject(dir string) (*DdevApp, error) {
+	app, err := LoadProject(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = app.Stop(false, false); err != nil {
+		return app, fmt.Errorf("failed to stop project %s: %v", app.Name, err)
+	}
+	return app, nil
+}
+
+// RestartProject loads the project in dir and restarts it.
+func RestartProject(dir string) (*DdevApp, error) {
+	app, err := LoadProject(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = app.Restart(); err != nil {
+		return app, fmt.Errorf("failed to restart project %s: %v", app.Name, err)
+	}
+	return app, nil
+}
+
+// PauseProject loads the project in dir and pauses it, stopping its
+// containers without removing them.
+func PauseProject(dir string) (*DdevApp, error) {
+	app, err := LoadProject(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = app.Pause(); err != nil {
+		return app, fmt.Errorf("failed to pause project %s: %v", app.Name, err)
+	}
+	return app, nil
+}
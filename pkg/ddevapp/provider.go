@@ -342,11 +342,11 @@ func (p *Provider) getDatabaseBackup() (filename string, error error) {
 
 // importDatabaseBackup will import a downloaded database
 // If a custom importer is provided, that will be used, otherwise
-// the default is app.ImportDB()
+// the default is app.ForceImportDB()
 func (p *Provider) importDatabaseBackup(fileLocation string, importPath string) error {
 	var err error
 	if p.DBImportCommand.Command == "" {
-		err = p.app.ImportDB(fileLocation, importPath, true, false, "db")
+		err = p.app.ForceImportDB(fileLocation, importPath, true, false, "db")
 	} else {
 		s := p.DBImportCommand.Service
 		if s == "" {
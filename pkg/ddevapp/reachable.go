@@ -0,0 +1,33 @@
+package ddevapp
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ReachabilityHTTPClient makes the request of IsReachable. It can be
+// replaced, for example to use a different timeout.
+var ReachabilityHTTPClient HTTPGetter = &http.Client{Timeout: 5 * time.Second}
+
+// IsReachable makes a single GET request to the project's URL() from the
+// host and reports whether the site answered. Any response counts, even an
+// error page, except the 502, 503 and 504 the router returns when the
+// project's web container isn't there. Failing to connect at all isn't an
+// error, it's just not reachable.
+func (app *DdevApp) IsReachable() (bool, error) {
+	url := app.URL()
+	if url == "" {
+		return false, fmt.Errorf("project %s has no URL", app.Name)
+	}
+	resp, err := ReachabilityHTTPClient.Get(url)
+	if err != nil {
+		return false, nil
+	}
+	_ = resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,4 @@
+package ddevapp
+
+// RedisService is the docker-compose service name of the optional redis container.
+const RedisService = "redis"
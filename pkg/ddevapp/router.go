@@ -164,6 +164,24 @@ func generateRouterCompose() (string, error) {
 	return routerComposeFullPath, nil
 }
 
+// ConnectRouterToNetwork attaches the running router to the named docker
+// network, so it can reach projects that use a network_name of their own.
+func ConnectRouterToNetwork(netName string) error {
+	router, err := FindDdevRouter()
+	if err != nil {
+		return err
+	}
+	if _, ok := router.Networks.Networks[netName]; ok {
+		return nil
+	}
+	client := dockerutil.GetDockerClient()
+	err = client.ConnectNetwork(netName, docker.NetworkConnectionOptions{Container: router.ID})
+	if err != nil {
+		return fmt.Errorf("failed to connect ddev-router to network %s: %v", netName, err)
+	}
+	return nil
+}
+
 // FindDdevRouter uses FindContainerByLabels to get our router container and
 // return it.
 func FindDdevRouter() (*docker.APIContainers, error) {
@@ -0,0 +1,71 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/drud/ddev/pkg/fileutil"
+	"gopkg.in/yaml.v2"
+)
+
+// SecretsFile is the file in .ddev with variables for the web container
+// that shouldn't be in config.yaml, like passwords and API keys. It's
+// listed in .ddev/.gitignore.
+const SecretsFile = "secrets.yaml"
+
+var envVarNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// GetSecrets returns the variables in the project's .ddev/secrets.yaml,
+// which is a map of names to values. There are none if it doesn't exist.
+func (app *DdevApp) GetSecrets() (map[string]string, error) {
+	secrets := map[string]string{}
+	secretsPath := app.GetConfigPath(SecretsFile)
+	if !fileutil.FileExists(secretsPath) {
+		return secrets, nil
+	}
+	contents, err := os.ReadFile(secretsPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.UnmarshalStrict(contents, &secrets); err != nil {
+		return nil, fmt.Errorf("unable to parse %s, it must contain only names and values like API_KEY: xyz: %v", secretsPath, err)
+	}
+	for name, value := range secrets {
+		if !envVarNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid variable name %q in %s", name, secretsPath)
+		}
+		if strings.ContainsAny(value, "\n\r") {
+			return nil, fmt.Errorf("the value of %s in %s must be a single line", name, secretsPath)
+		}
+	}
+	return secrets, nil
+}
+
+// withSecrets returns webEnvironment with the variables from secrets.yaml
+// added, replacing any variables of the same name. The values are escaped
+// for the double-quoted web_environment entries of the compose template,
+// and so docker-compose doesn't interpolate them.
+func withSecrets(webEnvironment []string, secrets map[string]string) []string {
+	if len(secrets) == 0 {
+		return webEnvironment
+	}
+	env := []string{}
+	for _, e := range webEnvironment {
+		if _, ok := secrets[strings.SplitN(e, "=", 2)[0]]; !ok {
+			env = append(env, e)
+		}
+	}
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$")
+	for _, name := range names {
+		env = append(env, name+"="+escaper.Replace(secrets[name]))
+	}
+	return env
+}
@@ -365,3 +365,38 @@ func TestDrupalBackdropOverwriteDdevSettings(t *testing.T) {
 		assert.True(containsOriginalString, "Did not find %s in the settings file; it should have still been there", originalContents)
 	}
 }
+
+// TestDdevAppWriteSettings tests that WriteSettings picks up configuration changes
+func TestDdevAppWriteSettings(t *testing.T) {
+	assert := asrt.New(t)
+	testDir := testcommon.CreateTmpDir(t.Name())
+	t.Cleanup(func() {
+		_ = os.Chmod(filepath.Join(testDir, "sites", "site1"), 0755)
+		_ = os.RemoveAll(testDir)
+	})
+
+	app := &DdevApp{}
+	err := app.InitWithType(testDir, nodeps.AppTypeDrupal9)
+	require.NoError(t, err)
+	app.Name = t.Name()
+
+	siteSettings := filepath.Join(testDir, "sites", "site1", "settings.ddev.php")
+	for _, dbName := range []string{"firstdb", "seconddb"} {
+		app.Multisites = []Multisite{{Name: "site1", Database: dbName}}
+		err = app.WriteConfig()
+		require.NoError(t, err)
+		err = app.WriteSettings()
+		require.NoError(t, err)
+
+		content, err := fileutil.ReadFileIntoString(siteSettings)
+		require.NoError(t, err)
+		assert.Contains(content, `"`+dbName+`"`)
+		if dbName == "seconddb" {
+			assert.NotContains(content, "firstdb")
+		}
+	}
+
+	app.DisableSettingsManagement = true
+	err = app.WriteSettings()
+	assert.Error(err)
+}
@@ -0,0 +1,56 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// dbSlowQueryLogPath is where the db container writes the slow query log
+// when db_long_query_time is set. It's in the datadir because mysqld runs
+// as the host user, who can't write to /var/log.
+const dbSlowQueryLogPath = "/var/lib/mysql/mysql-slow.log"
+
+// dbSlowQueryCnf is mounted into the db container when db_long_query_time
+// is set. The base my.cnf sends slow queries to the error log, which is
+// the container's stderr, so they get a file of their own here.
+const dbSlowQueryCnf = `# ` + DdevFileSignature + `
+[mysqld]
+slow-query-log=1
+slow-query-log-file=` + dbSlowQueryLogPath + `
+long-query-time=%s
+`
+
+// WriteDBSlowQueryConfig writes the mysqld configuration for the slow query
+// log into .ddev/.dbslowlog
+func (app *DdevApp) WriteDBSlowQueryConfig() error {
+	cnfPath := app.GetConfigPath(filepath.Join(".dbslowlog", "slow-query.cnf"))
+	err := os.MkdirAll(filepath.Dir(cnfPath), 0755)
+	if err != nil {
+		return err
+	}
+	cnf := fmt.Sprintf(dbSlowQueryCnf, strconv.FormatFloat(app.DBLongQueryTime, 'f', -1, 64))
+	return os.WriteFile(cnfPath, []byte(cnf), 0644)
+}
+
+// SlowQueryLog writes the db slow query log to stdout. If follow is true it
+// keeps writing new entries until it's interrupted.
+func (app *DdevApp) SlowQueryLog(follow bool) error {
+	if app.DBLongQueryTime <= 0 {
+		return fmt.Errorf("the slow query log is not enabled for project %s, set db_long_query_time and restart", app.Name)
+	}
+	cmd := "tail -n +1 " + dbSlowQueryLogPath
+	if follow {
+		cmd = "tail -n +1 -F " + dbSlowQueryLogPath
+	}
+	_, _, err := app.Exec(&ExecOpts{
+		Service:   "db",
+		Cmd:       cmd,
+		NoCapture: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the slow query log: %v", err)
+	}
+	return nil
+}
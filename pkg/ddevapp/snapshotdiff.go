@@ -0,0 +1,138 @@
+package ddevapp
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+	"github.com/drud/ddev/pkg/fileutil"
+	"github.com/drud/ddev/pkg/globalconfig"
+	"github.com/drud/ddev/pkg/util"
+)
+
+// snapshotRowCountsScript prepares a snapshot in a temporary directory in
+// the db container, starts a second mysqld on it and prints
+// "schema.table<tab>rows" for each table. Backticks are written as
+// CHAR(96) so the script can be a raw string.
+const snapshotRowCountsScript = `set -eu -o pipefail
+snapshot="/mnt/snapshots/%s"
+dir=$(mktemp -d /var/tmp/snapshotdiff.XXXXXX)
+trap 'mysqladmin --socket="$dir/mysql.sock" shutdown >/dev/null 2>&1 || true; rm -rf "$dir" "$dir.err"' EXIT
+if [ -d "$snapshot" ]; then
+  cp -r "$snapshot/." "$dir/"
+else
+  streamtool=mbstream
+  if command -v xtrabackup >/dev/null 2>&1; then streamtool=xbstream; fi
+  gunzip -c "$snapshot" | $streamtool -x -C "$dir"
+fi
+if ! out=$($(/backuptool.sh) --prepare --skip-innodb-use-native-aio --target-dir "$dir" --user=root --password=root 2>&1); then
+  echo "$out" >&2
+  exit 1
+fi
+PATH=$PATH:/usr/sbin:/usr/local/bin:/usr/local/mysql/bin mysqld --datadir="$dir" --socket="$dir/mysql.sock" --pid-file="$dir/mysql.pid" --log-error="$dir.err" --slow-query-log=0 --skip-networking --skip-grant-tables --innodb-buffer-pool-size=64M --innodb-use-native-aio=0 >/dev/null 2>&1 &
+for i in $(seq 60); do
+  if mysqladmin --socket="$dir/mysql.sock" ping >/dev/null 2>&1; then break; fi
+  sleep 1
+done
+mysql --socket="$dir/mysql.sock" -N -B -e "SELECT CONCAT('SELECT ''', table_schema, '.', table_name, ''', COUNT(*) FROM ', CHAR(96), table_schema, CHAR(96), '.', CHAR(96), table_name, CHAR(96), ';') FROM information_schema.tables WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')" | mysql --socket="$dir/mysql.sock" -N -B
+`
+
+// DiffSnapshots compares the row count of each table in snapshots a and b,
+// which must have been made with the project's database server version.
+// It returns a line for each table whose row count differs, like
+// "db.users: 2 -> 3", or "" if there are no differences. This is a
+// lightweight comparison; changes to the schema or to the contents of rows
+// are not reported.
+func (app *DdevApp) DiffSnapshots(a, b string) (string, error) {
+	err := app.Wait([]string{"db"})
+	if err != nil {
+		return "", fmt.Errorf("unable to compare snapshots, the db container of project %s is not running", app.Name)
+	}
+
+	countsA, err := app.snapshotRowCounts(a)
+	if err != nil {
+		return "", err
+	}
+	countsB, err := app.snapshotRowCounts(b)
+	if err != nil {
+		return "", err
+	}
+
+	tables := []string{}
+	for table := range countsA {
+		tables = append(tables, table)
+	}
+	for table := range countsB {
+		if _, ok := countsA[table]; !ok {
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+
+	describe := func(counts map[string]int, table string) string {
+		if n, ok := counts[table]; ok {
+			return strconv.Itoa(n)
+		}
+		return "missing"
+	}
+	diff := []string{}
+	for _, table := range tables {
+		before, after := describe(countsA, table), describe(countsB, table)
+		if before != after {
+			diff = append(diff, fmt.Sprintf("%s: %s -> %s", table, before, after))
+		}
+	}
+	if len(diff) == 0 {
+		return "", nil
+	}
+	return strings.Join(diff, "\n") + "\n", nil
+}
+
+// snapshotRowCounts returns the number of rows of each table in a snapshot,
+// keyed by schema.table.
+func (app *DdevApp) snapshotRowCounts(snapshotName string) (map[string]int, error) {
+	hostSnapshot, err := app.checkSnapshotCompatible(snapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without bind mounts the snapshot has to be copied into the snapshots
+	// volume, as RestoreSnapshot() does, but without recreating the volume,
+	// which the running db container uses.
+	if globalconfig.DdevGlobalConfig.NoBindMounts {
+		uid, _, _ := util.GetContainerUIDGid()
+		subdir := ""
+		if fileutil.IsDirectory(hostSnapshot) {
+			subdir = snapshotName
+		}
+		err = dockerutil.CopyIntoVolume(filepath.Join(app.GetConfigPath("db_snapshots"), snapshotName), "ddev-"+app.Name+"-snapshots", subdir, uid, "", false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stdout, stderr, err := app.Exec(&ExecOpts{
+		Service: "db",
+		Cmd:     fmt.Sprintf(snapshotRowCountsScript, snapshotName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %v, stderr=%s", snapshotName, err, stderr)
+	}
+
+	counts := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected row count %q for %s in snapshot %s", fields[1], fields[0], snapshotName)
+		}
+		counts[fields[0]] = n
+	}
+	return counts, nil
+}
@@ -0,0 +1,32 @@
+package ddevapp
+
+import (
+	"fmt"
+
+	"github.com/drud/ddev/pkg/dockerutil"
+)
+
+// SolrService is the docker-compose service name of the optional solr container.
+const SolrService = "solr"
+
+// GetSolrVolumeName returns the docker volume name of the solr data volume
+func (app *DdevApp) GetSolrVolumeName() string {
+	return app.Name + "-solr"
+}
+
+// SolrURL returns the URL of the solr admin UI on the host,
+// or "" if solr is not enabled or not running.
+func (app *DdevApp) SolrURL() string {
+	if !app.SolrEnabled {
+		return ""
+	}
+	port, err := app.GetPublishedPort(SolrService)
+	if err != nil || port <= 0 {
+		return ""
+	}
+	dockerIP, err := dockerutil.GetDockerIP()
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("http://%s:%d/solr/", dockerIP, port)
+}
@@ -0,0 +1,39 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/drud/ddev/pkg/util"
+)
+
+// ExecSQLFile runs the statements in the SQL file at sqlPath against the
+// project's db database, for small changes like a patch that adds a
+// column. Unlike ImportDB the database isn't dropped first and no import
+// hooks are run; the file is streamed straight into the mysql client.
+// It stops at the first statement that fails.
+func (app *DdevApp) ExecSQLFile(sqlPath string) error {
+	f, err := os.Open(sqlPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrImportSourceNotFound, sqlPath, err)
+	}
+	defer util.CheckClose(f)
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory, not an SQL file", sqlPath)
+	}
+
+	_, _, err = app.Exec(&ExecOpts{
+		Service:   "db",
+		Cmd:       "mysql db",
+		NoCapture: true,
+		Stdin:     f,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %v", sqlPath, err)
+	}
+	return nil
+}
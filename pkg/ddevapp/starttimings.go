@@ -0,0 +1,71 @@
+package ddevapp
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// startTimingsFile is the file in .ddev where Start() records how long
+// each of its steps took
+const startTimingsFile = ".start-timings.json"
+
+// startTimer measures the steps of Start(). Each step is the time since
+// the previous one; a step that's recorded more than once adds up.
+type startTimer struct {
+	timings   map[string]time.Duration
+	start     time.Time
+	stepStart time.Time
+}
+
+func newStartTimer() *startTimer {
+	now := time.Now()
+	return &startTimer{timings: map[string]time.Duration{}, start: now, stepStart: now}
+}
+
+// step records the time since the previous step as name
+func (t *startTimer) step(name string) {
+	now := time.Now()
+	t.timings[name] += now.Sub(t.stepStart)
+	t.stepStart = now
+}
+
+// finish records the total and returns all the timings
+func (t *startTimer) finish() map[string]time.Duration {
+	t.timings["total"] = time.Since(t.start)
+	return t.timings
+}
+
+// writeStartTimings saves the timings of the last Start()
+func (app *DdevApp) writeStartTimings(timings map[string]time.Duration) error {
+	durations := map[string]string{}
+	for step, d := range timings {
+		durations[step] = d.String()
+	}
+	content, err := json.MarshalIndent(durations, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(app.GetConfigPath(startTimingsFile), content, 0644)
+}
+
+// LastStartTimings returns how long the steps of the project's last
+// successful Start() took, like "pull", "compose_up", "wait" and "total".
+// It's empty if the project hasn't been started since it was recorded.
+func (app *DdevApp) LastStartTimings() map[string]time.Duration {
+	timings := map[string]time.Duration{}
+	content, err := os.ReadFile(app.GetConfigPath(startTimingsFile))
+	if err != nil {
+		return timings
+	}
+	durations := map[string]string{}
+	if err := json.Unmarshal(content, &durations); err != nil {
+		return timings
+	}
+	for step, s := range durations {
+		if d, err := time.ParseDuration(s); err == nil {
+			timings[step] = d
+		}
+	}
+	return timings
+}
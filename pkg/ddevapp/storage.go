@@ -0,0 +1,123 @@
+package ddevapp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/drud/ddev/pkg/util"
+)
+
+// DumpSource is somewhere a database dump can be read from, like a local
+// file or an object in a storage bucket.
+type DumpSource interface {
+	// Name identifies the dump. Its extension, like .sql.gz or .zip,
+	// determines how the dump is extracted.
+	Name() string
+	// Open returns a reader for the contents of the dump
+	Open() (io.ReadCloser, error)
+}
+
+// DumpSink is somewhere a database dump can be written to.
+type DumpSink interface {
+	// Name identifies where the dump goes, for messages.
+	Name() string
+	// Create returns a writer that replaces any existing contents
+	Create() (io.WriteCloser, error)
+}
+
+// LocalFile is a DumpSource and DumpSink for a file on the host.
+// It is what ImportDB and ExportDB use.
+type LocalFile struct {
+	Path string
+}
+
+// Name returns the path of the file
+func (f LocalFile) Name() string {
+	return f.Path
+}
+
+// Open opens the file for reading
+func (f LocalFile) Open() (io.ReadCloser, error) {
+	return os.Open(f.Path)
+}
+
+// Create creates or truncates the file for writing
+func (f LocalFile) Create() (io.WriteCloser, error) {
+	return os.OpenFile(f.Path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+}
+
+// ImportDBFromSource is like ForceImportDB, but it reads the dump from src.
+func (app *DdevApp) ImportDBFromSource(src DumpSource, extPath string, progress bool, noDrop bool, targetDB string) error {
+	if f, ok := src.(LocalFile); ok {
+		return app.ForceImportDB(f.Path, extPath, progress, noDrop, targetDB)
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(app.ConfigPath), ".importsource")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	r, err := src.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", src.Name(), err)
+	}
+	defer util.CheckClose(r)
+	tmpFile := filepath.Join(tmpDir, filepath.Base(src.Name()))
+	w, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", src.Name(), err)
+	}
+
+	return app.ForceImportDB(tmpFile, extPath, progress, noDrop, targetDB)
+}
+
+// ExportDBToSink is like ExportDB, but it writes the dump to sink.
+func (app *DdevApp) ExportDBToSink(sink DumpSink, gzip bool, targetDB string) error {
+	if f, ok := sink.(LocalFile); ok {
+		return app.ExportDB(f.Path, gzip, targetDB)
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(app.ConfigPath), ".exportsink")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	tmpFile := filepath.Join(tmpDir, "db.sql")
+	err = app.ExportDB(tmpFile, gzip, targetDB)
+	if err != nil {
+		return err
+	}
+
+	r, err := os.Open(tmpFile)
+	if err != nil {
+		return err
+	}
+	defer util.CheckClose(r)
+	w, err := sink.Create()
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", sink.Name(), err)
+	}
+	_, err = io.Copy(w, r)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", sink.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package ddevapp
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/drud/ddev/pkg/fileutil"
+	"github.com/drud/ddev/pkg/nodeps"
+	"gopkg.in/yaml.v2"
+)
+
+// supportBundleLogLines is how many lines of each container's log go into
+// a support bundle
+const supportBundleLogLines = "500"
+
+// SupportBundle writes a zip file to outputPath with what's needed for a
+// bug report: the rendered docker-compose file, the project config,
+// the output of Describe and the recent web and db logs. The db password,
+// the values of web_environment and the secrets from secrets.yaml are
+// masked in all of them. The compose file is only there once the project
+// has been started.
+func (app *DdevApp) SupportBundle(outputPath string) error {
+	if app.AppRoot == "" {
+		return fmt.Errorf("project has not been initialized")
+	}
+	secrets, err := app.GetSecrets()
+	if err != nil {
+		return err
+	}
+	redactor := app.supportBundleRedactor(secrets)
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+	add := func(name string, contents []byte) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(contents)
+		return err
+	}
+
+	err = app.writeSupportBundle(add, secrets, redactor)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(outputPath)
+		return fmt.Errorf("failed to write support bundle %s: %v", outputPath, err)
+	}
+	return nil
+}
+
+// writeSupportBundle adds the entries of the support bundle with add
+func (app *DdevApp) writeSupportBundle(add func(string, []byte) error, secrets map[string]string, redactor *strings.Replacer) error {
+	if composePath := app.DockerComposeFullRenderedYAMLPath(); fileutil.FileExists(composePath) {
+		contents, err := os.ReadFile(composePath)
+		if err != nil {
+			return err
+		}
+		webEnvNames := map[string]bool{}
+		for _, env := range app.WebEnvironment {
+			webEnvNames[strings.SplitN(env, "=", 2)[0]] = true
+		}
+		for name := range secrets {
+			webEnvNames[name] = true
+		}
+		contents, err = redactComposeEnvironment(contents, webEnvNames)
+		if err != nil {
+			return err
+		}
+		if err = add("docker-compose.yaml", []byte(redactor.Replace(string(contents)))); err != nil {
+			return err
+		}
+	}
+
+	appcopy := *app
+	appcopy.WebEnvironment = []string{}
+	for _, env := range app.WebEnvironment {
+		appcopy.WebEnvironment = append(appcopy.WebEnvironment, strings.SplitN(env, "=", 2)[0]+"="+debugInfoMask)
+	}
+	config, err := yaml.Marshal(appcopy)
+	if err != nil {
+		return err
+	}
+	if err = add("config.yaml", []byte(redactor.Replace(string(config)))); err != nil {
+		return err
+	}
+
+	desc, err := app.Describe(false)
+	if err != nil {
+		return err
+	}
+	if dbinfo, ok := desc["dbinfo"].(map[string]interface{}); ok {
+		dbinfo["password"] = debugInfoMask
+	}
+	describe, err := json.MarshalIndent(desc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = add("describe.json", []byte(redactor.Replace(string(describe)))); err != nil {
+		return err
+	}
+
+	services := []string{"web"}
+	if !nodeps.ArrayContainsString(app.GetOmittedContainers(), "db") {
+		services = append(services, "db")
+	}
+	for _, service := range services {
+		container, err := app.FindContainerByType(service)
+		if err != nil {
+			return err
+		}
+		if container == nil {
+			continue
+		}
+		logs, err := app.CaptureLogs(service, true, supportBundleLogLines)
+		if err != nil {
+			return err
+		}
+		if err = add("logs/"+service+".log", []byte(redactor.Replace(logs))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// supportBundleRedactor returns a replacer that masks the values of the
+// secrets and of web_environment variables that look like credentials,
+// wherever they appear in text like logs
+func (app *DdevApp) supportBundleRedactor(secrets map[string]string) *strings.Replacer {
+	var pairs []string
+	for _, value := range secrets {
+		if value != "" {
+			pairs = append(pairs, value, debugInfoMask)
+		}
+	}
+	for _, env := range app.WebEnvironment {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 2 && parts[1] != "" && isSensitiveEnvName(parts[0]) {
+			pairs = append(pairs, parts[1], debugInfoMask)
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// isSensitiveEnvName reports whether an environment variable's name says
+// it holds a credential
+func isSensitiveEnvName(name string) bool {
+	name = strings.ToUpper(name)
+	for _, s := range []string{"PASSWORD", "SECRET", "TOKEN"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactComposeEnvironment masks the values of the environment variables
+// in a rendered compose file that look like credentials, and those of the
+// web service named in webEnvNames
+func redactComposeEnvironment(contents []byte, webEnvNames map[string]bool) ([]byte, error) {
+	compose := map[string]interface{}{}
+	if err := yaml.Unmarshal(contents, &compose); err != nil {
+		return nil, err
+	}
+	services, ok := compose["services"].(map[interface{}]interface{})
+	if !ok {
+		return contents, nil
+	}
+	for serviceName, s := range services {
+		service, ok := s.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		mask := func(name string) bool {
+			return isSensitiveEnvName(name) || (serviceName == "web" && webEnvNames[name])
+		}
+		switch env := service["environment"].(type) {
+		case map[interface{}]interface{}:
+			for k, v := range env {
+				if v != nil && mask(fmt.Sprint(k)) {
+					env[k] = debugInfoMask
+				}
+			}
+		case []interface{}:
+			for i, e := range env {
+				parts := strings.SplitN(fmt.Sprint(e), "=", 2)
+				if len(parts) == 2 && mask(parts[0]) {
+					env[i] = parts[0] + "=" + debugInfoMask
+				}
+			}
+		}
+	}
+	return yaml.Marshal(compose)
+}
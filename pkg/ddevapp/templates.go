@@ -21,6 +21,12 @@ const ConfigInstructions = `
 # dbimage: <docker_image>  # mariadb docker image.
 # dbaimage: <docker_image>
 
+# image_registry: myregistry.local/ddev
+# A registry or mirror to pull the webimage, dbimage and dbaimage from, for
+# networks where Docker Hub can't be reached. With this setting the webimage
+# is pulled as myregistry.local/ddev/drud/ddev-webserver:<tag>. Images that
+# already name a registry are pulled from that registry.
+
 # mariadb_version and mysql_version
 # ddev can use many versions of mariadb and mysql
 # However these directives are mutually exclusive
@@ -35,6 +41,9 @@ const ConfigInstructions = `
 # "ddev xdebug" to enable xdebug and "ddev xdebug off" to disable it work better,
 # as leaving xdebug enabled all the time is a big performance hit.
 
+# xdebug_port: 9003  # Port on the host that xdebug connects to, where your IDE listens
+# xdebug_ide_key: PHPSTORM  # The xdebug IDE key your editor expects
+
 # xhprof_enabled: false  # Set to true to enable xhprof and "ddev start" or "ddev restart"
 # Note that for most people the commands
 # "ddev xhprof" to enable xhprof and "ddev xhprof off" to disable it work better,
@@ -42,6 +51,15 @@ const ConfigInstructions = `
 
 # webserver_type: nginx-fpm  # or apache-fpm
 
+# webserver_workers: 4
+# The number of nginx worker processes, or of apache server processes,
+# in the web container. By default nginx starts one per CPU and apache 2.
+
+# fastcgi_buffers: 32 16k
+# fastcgi_buffer_size: 64k
+# The nginx buffers for responses from php-fpm. The defaults are "16 16k"
+# and 32k; pages with very large headers may need more.
+
 # timezone: Europe/Berlin
 # This is the timezone used in the containers and by PHP;
 # it can be set to any valid timezone,
@@ -55,6 +73,124 @@ const ConfigInstructions = `
 # It can be set to any existing specific composer version.
 # After first project 'ddev start' this will not be updated until it changes
 
+# nodejs_version: "16"
+# Install this major version of nodejs in the web container instead of
+# the one bundled with the web image.
+
+# web_memory_limit: 2g
+# web_cpu_limit: "1.5"
+# db_memory_limit: 1g
+# db_cpu_limit: "1"
+# Limit the memory (like 512m or 2g) and number of cpus the web and db
+# containers may use.
+
+# network_name: ddev_myproject
+# The docker network the project's containers share with the router,
+# instead of the default ddev_default. It is created if it doesn't exist.
+
+# warmup_urls:
+#   - /
+#   - /node/1
+# URLs (or paths on the project's http URL) that are requested once after
+# 'ddev start', so the first real page load isn't slowed by cold caches.
+
+# healthcheck_interval: 5s
+# healthcheck_retries: 60
+# healthcheck_timeout: 300s
+# Timing of the container healthchecks, which default to an interval of 1s,
+# 120 retries and a timeout of 120s. Slow machines may need more generous values.
+
+# restart_policy: unless-stopped
+# Whether docker restarts the project's containers, for example after a reboot
+# of a server running ddev: "no", "unless-stopped" or "always". The default is
+# "no", or "always" with the global auto_restart_containers.
+
+# expose_db_port: false
+# Don't publish the database port to the host, so the database can only be
+# reached from the project's containers. host_db_port can't be used then,
+# nor can host tools like "ddev tableplus" connect to it.
+
+# existing_containers: recreate
+# What "ddev start" does with the project's containers when they already
+# exist, for example after "ddev pause": "reuse" them, which is the default
+# and fastest, or "recreate" them for a clean start. Containers created with
+# a different configuration are always recreated.
+
+# stop_grace_period: 30s
+# How long containers get to shut down cleanly on "ddev stop" before they
+# are killed. The default is docker's 10 seconds.
+
+# import_timeout: 30m
+# How long "ddev import-db" may take before the import is killed and fails.
+# By default there's no limit.
+
+# log_driver: json-file
+# log_max_size: 10m
+# log_max_file: 5
+# The docker logging driver of the project's containers. With the json-file
+# (default) and local drivers each container's log is rotated at
+# log_max_size, keeping log_max_file files, by default 10m and 5.
+
+# web_command: "php artisan queue:work"
+# A command that's run in the web container instead of the web server and
+# php-fpm, for projects like queue workers or front-end dev servers. The web
+# container is healthy as long as the command is running.
+
+# extra_hosts: ["myservice:10.0.0.5"]
+# Hostnames and their IP addresses added to the web container's /etc/hosts,
+# for reaching services that aren't in DNS, like ones only on the host's
+# network.
+
+# dns: [10.0.0.2, 10.0.0.3]
+# dns_search: [corp.example.com]
+# The DNS servers and search domains of the web and db containers, for
+# networks where internal hosts can only be resolved by the company's own
+# DNS servers. By default docker's are used.
+
+# generated_compose_dir: .generated/ddev
+# The directory, relative to the project root, that ddev writes the
+# generated .ddev-docker-compose-base.yaml and .ddev-docker-compose-full.yaml
+# to, instead of .ddev. Relative paths in .ddev/docker-compose.*.yaml files
+# are still relative to .ddev. Remember to add it to your .gitignore.
+
+# import_files_owner: www-data:www-data
+# The user, or user:group, that files imported with "ddev import-files" are
+# given in the web container. By default that's the user the web server
+# runs as, which is your own uid and gid.
+
+# directory_listing: true
+# Makes the web server list the files in a directory that has no index
+# file, instead of answering 403 Forbidden. Only used with the
+# ddev-generated nginx and apache configs.
+
+# validate_compose: true
+# Checks the generated docker-compose.yaml and the project's
+# docker-compose.*.yaml files with "docker-compose config" on "ddev start",
+# so that mistakes in them are reported before any container is changed.
+
+# web_env_file: config/app.env
+# A file of VAR=value lines, relative to the project root, whose variables
+# are put into the web container's environment. By default that's the
+# project's .env if there is one, except for laravel and shopware6, which
+# manage their .env themselves. web_environment and ddev's own variables
+# take precedence over it.
+
+# docroot_read_only: true
+# docroot_writable_dirs: [sites/default/files, tmp]
+# Mounts the docroot read-only in the web container, except for the
+# docroot_writable_dirs, which are relative to the docroot. By default only
+# the upload dir is writable. Not supported with mutagen or nfs.
+
+# multisites:
+#  - name: site1
+#  - name: site2
+#    hostname: othersite
+#    database: other
+# Drupal multisites, each served from sites/<name> with its own database.
+# The hostname, which defaults to <name>.<project name>, is handled like an
+# additional_hostnames entry and the database defaults to the name.
+# ddev writes sites/sites.php and the settings files of each site.
+
 # additional_hostnames:
 #  - somename
 #  - someothername
@@ -92,6 +228,11 @@ const ConfigInstructions = `
 # Experimental performance improvement using mutagen asynchronous updates.
 # See https://ddev.readthedocs.io/en/latest/users/performance/#using-mutagen
 
+# mount_type: cached
+# How the project is mounted into the web container: "bind", "cached",
+# "delegated" or "sync". "cached" and "delegated" make bind mounts faster
+# with Docker Desktop for Mac, and "sync" uses mutagen like mutagen_enabled.
+
 # fail_on_hook_fail: False
 # Decide whether 'ddev start' should be interrupted by a failing hook
 
@@ -154,6 +295,12 @@ const ConfigInstructions = `
 # Drupal's settings.php/settings.ddev.php or TYPO3's AdditionalConfiguration.php
 # In this case the user must provide all such settings.
 
+# disable_hosts_management: false
+# If true, ddev never adds the project's hostnames to the hosts file or
+# removes them from it, which needs sudo. Hostnames that can't be resolved
+# by DNS then don't work, but the project can still be reached on its
+# 127.0.0.1:<port> URL shown by "ddev describe".
+
 # You can inject environment variables into the web container with:
 # web_environment:
 # - SOMEENV=somevalue
@@ -171,6 +318,52 @@ const ConfigInstructions = `
 # will be available on the local network if the host firewall
 # allows it.
 
+# db_replica_enabled: false
+# If true, an extra "db-replica" container is started which replicates
+# the db container. It is only available inside the docker network,
+# using the hostname "db-replica".
+
+# db_long_query_time: 0.5
+# If set, queries taking longer than this many seconds are written to the
+# db slow query log, which SlowQueryLog() shows.
+
+# composer_cache_mount_enabled: false
+# If true, the host's composer cache directory (as composer would find it,
+# for example ~/.cache/composer) is mounted into the web container, so
+# "ddev composer install" can reuse packages already downloaded on the host.
+# Nothing is mounted if the directory doesn't exist.
+
+# fixture_db: ""
+# fixture_files: ""
+# A database dump and a files archive or directory (relative to the
+# project root) that the project can be reset to with ResetToFixture(),
+# which drops the database and clears the upload directory before
+# importing them.
+
+# solr_enabled: false
+# If true, a "solr" container (solr:8) is added to the project. It is
+# available inside the docker network with the hostname "solr", and
+# "ddev describe" shows the URL of the admin UI on the host.
+# host_solr_port: "8983"
+# The host port to publish solr on. If empty, a free port is chosen.
+
+# redis_enabled: false
+# If true, a "redis" container (redis:6) is added to the project, and
+# the web container gets REDIS_HOST and REDIS_PORT environment variables
+# pointing to it.
+
+# additional_mounts:
+#   - source: ../shared-libraries
+#     target: /var/www/shared-libraries
+# Host directories (absolute, or relative to the project root) to bind-mount
+# into the web container. The source directories must exist when the
+# project is started.
+
+# base_path: /app
+# Serve the site under this subdirectory of the project URL as well as at
+# its root, for example https://<projectname>.ddev.site/app
+# The generated nginx and apache configurations handle the subdirectory.
+
 # Many ddev commands can be extended to run tasks before or after the
 # ddev command is executed, for example "post-start", "post-import-db",
 # "pre-composer", "post-composer"
@@ -0,0 +1,6 @@
+-- Data-only dump for the users table in users.sql whose second
+-- statement fails, so a transactional import must roll back the first.
+LOCK TABLES `users` WRITE;
+INSERT INTO `users` VALUES (2,'5c2e4f3b-8f0d-4a55-9f5b-2b0c1f3f6d11','en'),(3,'a9e1d6c4-6f0e-4b8e-8d1b-7f3c2e5a4b22','en');
+INSERT INTO `users` VALUES (1,'186efa0a-8aa3-4eeb-90ce-6302fb9c4e07','en');
+UNLOCK TABLES;
@@ -0,0 +1,13 @@
+-- MySQL dump of a database that was not called db
+CREATE DATABASE /*!32312 IF NOT EXISTS*/ `otherdb` /*!40100 DEFAULT CHARACTER SET utf8mb4 */;
+
+USE `otherdb`;
+
+DROP TABLE IF EXISTS `users`;
+CREATE TABLE `users` (
+  `uid` int(10) unsigned NOT NULL,
+  `name` varchar(60) NOT NULL DEFAULT '',
+  PRIMARY KEY (`uid`)
+);
+
+INSERT INTO `users` VALUES (1,'admin'),(2,'editor');
@@ -10,6 +10,7 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"os"
@@ -19,6 +20,7 @@ import (
 	"github.com/drud/ddev/pkg/dockerutil"
 	"github.com/drud/ddev/pkg/fileutil"
 	"github.com/drud/ddev/pkg/util"
+	"github.com/mitchellh/go-homedir"
 )
 
 // GetActiveProjects returns an array of ddev projects
@@ -54,6 +56,28 @@ func GetActiveProjects() []*DdevApp {
 	return apps
 }
 
+// ActivePorts returns the name of the project that owns each host port
+// published by the containers of the running projects, like the web
+// server port, mailhog's and the db's. The ports of the router, which is
+// shared by all projects, aren't included.
+func ActivePorts() (map[int]string, error) {
+	ports := map[int]string{}
+	for _, app := range GetActiveProjects() {
+		containers, err := dockerutil.GetAppContainers(app.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range containers {
+			for _, p := range c.Ports {
+				if p.PublicPort != 0 {
+					ports[int(p.PublicPort)] = app.Name
+				}
+			}
+		}
+	}
+	return ports, nil
+}
+
 // RenderHomeRootedDir shortens a directory name to replace homedir with ~
 func RenderHomeRootedDir(path string) string {
 	userDir, err := os.UserHomeDir()
@@ -70,6 +94,11 @@ func RenderAppRow(t table.Writer, row map[string]interface{}) {
 	mutagenStatus := ""
 	if row["status"] == SiteRunning {
 		urls = row["primary_url"].(string)
+		if multisites, ok := row["multisites"].([]map[string]string); ok {
+			for _, site := range multisites {
+				urls = urls + "\n" + site["url"]
+			}
+		}
 		if row["mutagen_enabled"] == true {
 			if _, ok := row["mutagen_status"]; ok {
 				mutagenStatus = row["mutagen_status"].(string)
@@ -91,11 +120,54 @@ func RenderAppRow(t table.Writer, row map[string]interface{}) {
 
 }
 
+// StopError is returned when some of a project's containers could not be
+// removed. It lists what was removed and what was not.
+type StopError struct {
+	// Removed lists the containers that were removed
+	Removed []string
+	// NotRemoved maps the containers that are left to the reason
+	NotRemoved map[string]error
+}
+
+func (e *StopError) Error() string {
+	notRemoved := make([]string, 0, len(e.NotRemoved))
+	for name, err := range e.NotRemoved {
+		notRemoved = append(notRemoved, fmt.Sprintf("%s (%v)", name, err))
+	}
+	sort.Strings(notRemoved)
+	return fmt.Sprintf("could not remove all containers; removed: [%s]; not removed: [%s]", strings.Join(e.Removed, ", "), strings.Join(notRemoved, ", "))
+}
+
+// errNotAttempted is the reason given for containers that were not removed
+// because an earlier container could not be removed.
+var errNotAttempted = fmt.Errorf("not attempted after an earlier failure")
+
+// removeContainer removes a container during Cleanup(). Tests replace it
+// to simulate containers that can't be removed.
+var removeContainer = func(opts docker.RemoveContainerOptions) error {
+	return dockerutil.GetDockerClient().RemoveContainer(opts)
+}
+
+// stopContainer stops a container during Cleanup(), killing it if it
+// hasn't exited after timeout seconds. Tests replace it to see the timeout.
+var stopContainer = func(id string, timeout uint) error {
+	return dockerutil.GetDockerClient().StopContainer(id, timeout)
+}
+
+// composeDownCmd runs docker-compose down during Cleanup(). Tests replace
+// it to see the arguments.
+var composeDownCmd = dockerutil.ComposeCmd
+
 // Cleanup will remove ddev containers and volumes even if docker-compose.yml
-// has been deleted.
+// has been deleted. It stops at the first container that can't be removed.
 func Cleanup(app *DdevApp) error {
-	client := dockerutil.GetDockerClient()
+	return cleanup(app, false)
+}
 
+// cleanup does the work of Cleanup(). If force is true it goes on past
+// containers that can't be removed and removes everything it can.
+// In either case a *StopError tells which containers are left.
+func cleanup(app *DdevApp, force bool) error {
 	// Find all containers which match the current site name.
 	labels := map[string]string{
 		"com.ddev.site-name": app.GetName(),
@@ -105,8 +177,13 @@ func Cleanup(app *DdevApp) error {
 	// "docker-compose down" - removes project network and any left-overs
 	// There can be awkward cases where we're doing an app.Stop() but the rendered
 	// yaml does not exist, all in testing situations.
+	gracePeriod := app.GetStopGracePeriod()
 	if fileutil.FileExists(app.DockerComposeFullRenderedYAMLPath()) {
-		_, _, err := dockerutil.ComposeCmd([]string{app.DockerComposeFullRenderedYAMLPath()}, "down")
+		downArgs := []string{"down"}
+		if gracePeriod > 0 {
+			downArgs = append(downArgs, "--timeout", strconv.Itoa(int(gracePeriod)))
+		}
+		_, _, err := composeDownCmd([]string{app.DockerComposeFullRenderedYAMLPath()}, downArgs...)
 		if err != nil {
 			util.Warning("Failed to docker-compose down: %v", err)
 		}
@@ -117,18 +194,36 @@ func Cleanup(app *DdevApp) error {
 	if err != nil {
 		return err
 	}
+	stopErr := &StopError{NotRemoved: map[string]error{}}
 	// First, try stopping the listed containers if they are running.
 	for i := range containers {
 		containerName := containers[i].Names[0][1:len(containers[i].Names[0])]
+		if len(stopErr.NotRemoved) > 0 && !force {
+			stopErr.NotRemoved[containerName] = errNotAttempted
+			continue
+		}
+		// With a grace period, give running containers the chance to
+		// flush to their volumes before the forced removal.
+		if gracePeriod > 0 && containers[i].State == "running" {
+			err = stopContainer(containers[i].ID, gracePeriod)
+			if _, notRunning := err.(*docker.ContainerNotRunning); err != nil && !notRunning {
+				util.Warning("Failed to stop container %s: %v", containerName, err)
+			}
+		}
 		removeOpts := docker.RemoveContainerOptions{
 			ID:            containers[i].ID,
 			RemoveVolumes: true,
 			Force:         true,
 		}
 		output.UserOut.Printf("Removing container: %s", containerName)
-		if err = client.RemoveContainer(removeOpts); err != nil {
-			return fmt.Errorf("could not remove container %s: %v", containerName, err)
+		if err = removeContainer(removeOpts); err != nil {
+			stopErr.NotRemoved[containerName] = err
+			continue
 		}
+		stopErr.Removed = append(stopErr.Removed, containerName)
+	}
+	if len(stopErr.NotRemoved) > 0 && !force {
+		return stopErr
 	}
 	// Always kill the temporary volumes on ddev remove
 	vols := []string{app.GetNFSMountVolumeName(), "ddev-" + app.Name + "-snapshots", app.Name + "-ddev-config"}
@@ -138,9 +233,41 @@ func Cleanup(app *DdevApp) error {
 	}
 
 	err = StopRouterIfNoContainers()
+	if len(stopErr.NotRemoved) > 0 {
+		return stopErr
+	}
 	return err
 }
 
+// CheckContainersOwnership makes sure that every container that has one of
+// the names ddev would give the project's containers really belongs to the
+// project, so that destructive operations can't touch an unrelated container
+// that happens to have a colliding name.
+func CheckContainersOwnership(app *DdevApp) error {
+	services := []string{"web", "db", "dba", DBReplicaService, SolrService, RedisService}
+	if s, ok := app.ComposeYaml["services"].(map[interface{}]interface{}); ok {
+		for k := range s {
+			if name, ok := k.(string); ok && !nodeps.ArrayContainsString(services, name) {
+				services = append(services, name)
+			}
+		}
+	}
+	for _, service := range services {
+		containerName := GetContainerName(app, service)
+		c, err := dockerutil.FindContainerByName(containerName)
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			continue
+		}
+		if c.Labels["com.ddev.site-name"] != app.Name || c.Labels["com.ddev.platform"] != "ddev" {
+			return fmt.Errorf("container %s does not belong to ddev project %s (it lacks the ddev labels), refusing to act on it; please rename or remove it", containerName, app.Name)
+		}
+	}
+	return nil
+}
+
 // CheckForConf checks for a config.yaml at the cwd or parent dirs.
 func CheckForConf(confPath string) (string, error) {
 	if fileutil.FileExists(filepath.Join(confPath, ".ddev", "config.yaml")) {
@@ -195,18 +322,64 @@ const gitIgnoreTemplate = `{{.Signature}}: Automatically generated ddev .gitigno
 /.gitignore
 {{range .IgnoredItems}}
 /{{.}}{{end}}
-`
+{{if .UserItems}}
+` + gitIgnoreUserMarker + `
+{{range .UserItems}}{{.}}
+{{end}}{{end}}`
+
+// gitIgnoreUserMarker starts the lines users added to a ddev-managed
+// .gitignore, which are kept when ddev updates the file.
+const gitIgnoreUserMarker = "# Lines below were added by the user and are kept when ddev updates this file"
 
 type ignoreTemplateContents struct {
 	Signature    string
 	IgnoredItems []string
+	UserItems    []string
+}
+
+// getGitIgnoreUserItems returns the lines a user added to a ddev-managed
+// .gitignore. Those are the lines after gitIgnoreUserMarker or, in a file
+// written before there was a marker, the lines that neither this nor an
+// older ddev version generated.
+func getGitIgnoreUserItems(gitIgnoreFilePath string, ignores []string) ([]string, error) {
+	content, err := fileutil.ReadFileIntoString(gitIgnoreFilePath)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(content, "\n")
+
+	generated := map[string]bool{"/.gitignore": true}
+	for _, i := range append(ignores, legacyDdevGitIgnores...) {
+		generated["/"+i] = true
+	}
+	for n, line := range lines {
+		if strings.TrimSpace(line) == gitIgnoreUserMarker {
+			lines = lines[n+1:]
+			generated = map[string]bool{}
+			break
+		}
+	}
+
+	userItems := []string{}
+	seen := map[string]bool{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || generated[line] || seen[line] || strings.Contains(line, DdevFileSignature) || strings.HasPrefix(line, "# You can remove the above line") {
+			continue
+		}
+		seen[line] = true
+		userItems = append(userItems, line)
+	}
+	return userItems, nil
 }
 
 // CreateGitIgnore will create a .gitignore file in the target directory if one does not exist.
 // Each value in ignores will be added as a new line to the .gitignore.
+// Lines the user added to an existing ddev-managed .gitignore are kept.
 func CreateGitIgnore(targetDir string, ignores ...string) error {
 	gitIgnoreFilePath := filepath.Join(targetDir, ".gitignore")
 
+	userItems := []string{}
 	if fileutil.FileExists(gitIgnoreFilePath) {
 		sigFound, err := fileutil.FgrepStringInFile(gitIgnoreFilePath, DdevFileSignature)
 		if err != nil {
@@ -218,6 +391,10 @@ func CreateGitIgnore(targetDir string, ignores ...string) error {
 			util.Warning("User-managed %s will not be managed/overwritten by ddev", gitIgnoreFilePath)
 			return nil
 		}
+		userItems, err = getGitIgnoreUserItems(gitIgnoreFilePath, ignores)
+		if err != nil {
+			return err
+		}
 		// Otherwise, remove the existing file to prevent surprising template results
 		err = os.Remove(gitIgnoreFilePath)
 		if err != nil {
@@ -251,6 +428,7 @@ func CreateGitIgnore(targetDir string, ignores ...string) error {
 	parms := ignoreTemplateContents{
 		Signature:    DdevFileSignature,
 		IgnoredItems: generatedIgnores,
+		UserItems:    userItems,
 	}
 
 	//nolint: revive
@@ -369,3 +547,26 @@ func GetProjects(activeOnly bool) ([]*DdevApp, error) {
 
 	return appSlice, nil
 }
+
+// validateImportSource makes sure the path given to ImportDB ("db") or
+// ImportFiles ("files") exists and can be read, so a typo is reported
+// before anything is done. A database dump has to be a file.
+func validateImportSource(importPath string, assetType string) error {
+	expanded, err := homedir.Expand(importPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrImportSourceNotFound, importPath, err)
+	}
+	f, err := os.Open(expanded)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrImportSourceNotFound, importPath, err)
+	}
+	defer util.CheckClose(f)
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrImportSourceNotFound, importPath, err)
+	}
+	if assetType == "db" && info.IsDir() {
+		return fmt.Errorf("%s is a directory, but a database dump must be a file or archive", importPath)
+	}
+	return nil
+}
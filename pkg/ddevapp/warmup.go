@@ -0,0 +1,47 @@
+package ddevapp
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/drud/ddev/pkg/util"
+)
+
+// HTTPGetter is the part of *http.Client used to fetch warmup_urls
+type HTTPGetter interface {
+	Get(url string) (*http.Response, error)
+}
+
+// warmupHTTPClient fetches the warmup_urls after Start. Tests replace it
+// to record the requests.
+var warmupHTTPClient HTTPGetter = &http.Client{Timeout: 60 * time.Second}
+
+// GetWarmupURLs returns the warmup_urls, with paths like /node/1 made
+// relative to the project's http URL.
+func (app *DdevApp) GetWarmupURLs() []string {
+	urls := make([]string, 0, len(app.WarmupURLs))
+	for _, u := range app.WarmupURLs {
+		if strings.HasPrefix(u, "/") {
+			u = app.GetHTTPURL() + u
+		}
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// Warmup fetches each of the warmup_urls once, so caches like opcache are
+// filled before the first real request. Failures only produce warnings.
+func (app *DdevApp) Warmup() {
+	for _, u := range app.GetWarmupURLs() {
+		resp, err := warmupHTTPClient.Get(u)
+		if err != nil {
+			util.Warning("Unable to warm up %s: %v", u, err)
+			continue
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			util.Warning("Warming up %s returned %s", u, resp.Status)
+		}
+	}
+}
@@ -42,6 +42,7 @@ func EnsureNetwork(client *docker.Client, name string) error {
 			Name:     name,
 			Driver:   "bridge",
 			Internal: false,
+			Labels:   map[string]string{"com.ddev.platform": "ddev"},
 		}
 		_, err := client.CreateNetwork(netOptions)
 		if err != nil {
@@ -245,6 +246,12 @@ func NetExists(client *docker.Client, name string) bool {
 // This is modeled on https://gist.github.com/ngauthier/d6e6f80ce977bedca601
 // Returns logoutput, error, returns error if not "healthy"
 func ContainerWait(waittime int, labels map[string]string) (string, error) {
+	return ContainerWaitWithProgress(waittime, labels, nil)
+}
+
+// ContainerWaitWithProgress is ContainerWait, but calls progress with the
+// container's health on each poll until it becomes healthy.
+func ContainerWaitWithProgress(waittime int, labels map[string]string, progress func(health string)) (string, error) {
 
 	timeoutChan := time.After(time.Duration(waittime) * time.Second)
 	tickChan := time.NewTicker(500 * time.Millisecond)
@@ -263,6 +270,10 @@ func ContainerWait(waittime int, labels map[string]string) (string, error) {
 				return "", fmt.Errorf("failed to query container labels=%v: %v", labels, err)
 			}
 			health, logOutput := GetContainerHealth(container)
+			status = health
+			if progress != nil && health != "healthy" {
+				progress(health)
+			}
 
 			switch health {
 			case "healthy":
@@ -431,8 +442,6 @@ func GetContainerHealth(container *docker.APIContainers) (string, string) {
 // ComposeWithStreams executes a docker-compose command but allows the caller to specify
 // stdin/stdout/stderr
 func ComposeWithStreams(composeFiles []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, action ...string) error {
-	var arg []string
-
 	runTime := util.TimeTrack(time.Now(), "dockerutil.ComposeWithStreams")
 	defer runTime()
 
@@ -441,6 +450,11 @@ func ComposeWithStreams(composeFiles []string, stdin io.Reader, stdout io.Writer
 		return err
 	}
 
+	path, arg, err := globalconfig.GetDockerComposeCommand()
+	if err != nil {
+		return err
+	}
+
 	for _, file := range composeFiles {
 		arg = append(arg, "-f")
 		arg = append(arg, file)
@@ -448,10 +462,6 @@ func ComposeWithStreams(composeFiles []string, stdin io.Reader, stdout io.Writer
 
 	arg = append(arg, action...)
 
-	path, err := globalconfig.GetDockerComposePath()
-	if err != nil {
-		return err
-	}
 	proc := exec.Command(path, arg...)
 	proc.Stdout = stdout
 	proc.Stdin = stdin
@@ -464,7 +474,6 @@ func ComposeWithStreams(composeFiles []string, stdin io.Reader, stdout io.Writer
 // ComposeCmd executes docker-compose commands via shell.
 // returns stdout, stderr, error/nil
 func ComposeCmd(composeFiles []string, action ...string) (string, string, error) {
-	var arg []string
 	var stdout bytes.Buffer
 	var stderr string
 
@@ -473,16 +482,17 @@ func ComposeCmd(composeFiles []string, action ...string) (string, string, error)
 		return "", "", err
 	}
 
+	path, arg, err := globalconfig.GetDockerComposeCommand()
+	if err != nil {
+		return "", "", err
+	}
+
 	for _, file := range composeFiles {
 		arg = append(arg, "-f", file)
 	}
 
 	arg = append(arg, action...)
 
-	path, err := globalconfig.GetDockerComposePath()
-	if err != nil {
-		return "", "", err
-	}
 	proc := exec.Command(path, arg...)
 	proc.Stdout = &stdout
 	proc.Stdin = os.Stdin
@@ -1112,6 +1122,20 @@ func CheckAvailableSpace() {
 	}
 }
 
+// GetAvailableSpace returns the number of bytes available on docker's
+// disk, where images, containers and volumes are stored.
+func GetAvailableSpace() (int64, error) {
+	_, out, err := RunSimpleContainer(version.GetWebImage(), "", []string{"sh", "-c", `df -Pk / | awk 'NR==2 {print $4;}'`}, []string{}, []string{}, []string{}, "", true, false, nil)
+	if err != nil {
+		return 0, fmt.Errorf("unable to check docker disk space: %v", err)
+	}
+	availableKB, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse docker disk space from '%s': %v", strings.TrimSpace(out), err)
+	}
+	return availableKB * 1024, nil
+}
+
 // DownloadDockerComposeIfNeeded downloads the proper version of docker-compose
 // if it's either not yet installed or has the wrong version.
 // Returns downloaded bool (true if it did the download) and err
@@ -1119,7 +1143,7 @@ func DownloadDockerComposeIfNeeded() (bool, error) {
 	requiredVersion := version.GetRequiredDockerComposeVersion()
 	var err error
 	if requiredVersion == "" {
-		util.Debug("globalconfig use_docker_compose_from_path is set, so not downloading")
+		util.Debug("globalconfig use_docker_compose_from_path or docker_compose_command is set, so not downloading")
 		return false, nil
 	}
 	curVersion, err := version.GetLiveDockerComposeVersion()
@@ -56,6 +56,7 @@ type GlobalConfig struct {
 	SimpleFormatting             bool                    `yaml:"simple_formatting"`
 	RequiredDockerComposeVersion string                  `yaml:"required_docker_compose_version,omitempty"`
 	UseDockerComposeFromPath     bool                    `yaml:"use_docker_compose_from_path,omitempty"`
+	DockerComposeCommand         string                  `yaml:"docker_compose_command,omitempty"`
 	NoBindMounts                 bool                    `yaml:"no_bind_mounts"`
 	MkcertCARoot                 string                  `yaml:"mkcert_caroot"`
 	ProjectList                  map[string]*ProjectInfo `yaml:"project_info"`
@@ -99,6 +100,31 @@ func GetDockerComposePath() (string, error) {
 	return filepath.Join(GetDDEVBinDir(), composeBinary), nil
 }
 
+// DockerComposeCommandEnv is the environment variable that can be used to
+// override the docker_compose_command global config
+const DockerComposeCommandEnv = "DDEV_DOCKER_COMPOSE_COMMAND"
+
+// GetCustomDockerComposeCommand returns the user-provided docker-compose command,
+// from $DDEV_DOCKER_COMPOSE_COMMAND or docker_compose_command, or "" if there isn't one.
+func GetCustomDockerComposeCommand() string {
+	if c := os.Getenv(DockerComposeCommandEnv); c != "" {
+		return c
+	}
+	return DdevGlobalConfig.DockerComposeCommand
+}
+
+// GetDockerComposeCommand returns the executable and any leading arguments
+// used to run docker-compose. This is normally just the docker-compose from
+// GetDockerComposePath(), but a custom command like "docker compose" can be
+// configured with docker_compose_command or $DDEV_DOCKER_COMPOSE_COMMAND.
+func GetDockerComposeCommand() (string, []string, error) {
+	if c := strings.Fields(GetCustomDockerComposeCommand()); len(c) > 0 {
+		return c[0], c[1:], nil
+	}
+	path, err := GetDockerComposePath()
+	return path, nil, err
+}
+
 // GetTableStyle returns the configured (string) table style
 func GetTableStyle() string {
 	return DdevGlobalConfig.TableStyle
@@ -282,6 +308,12 @@ func WriteGlobalConfig(config GlobalConfig) error {
 # to ~/.ddev/bin/docker-compose.
 # Please don't use this unless directed to do so
 
+# docker_compose_command: ""
+# This can be set to a command to use instead of ddev's private docker-compose,
+# for example "docker compose" to use the docker compose plugin, or a full path
+# to a docker-compose binary. ddev doesn't check its version.
+# The DDEV_DOCKER_COMPOSE_COMMAND environment variable overrides this.
+
 `
 	cfgbytes = append(cfgbytes, instructions...)
 
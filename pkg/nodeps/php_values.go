@@ -30,3 +30,12 @@ var ValidPHPVersions = map[string]bool{
 
 // Composer version default - will get latest composer v2
 var ComposerDefault = "2"
+
+// ValidNodeJSVersions is the list of nodejs major versions that can be
+// used as nodejs_version
+var ValidNodeJSVersions = map[string]bool{
+	"12": true,
+	"14": true,
+	"16": true,
+	"17": true,
+}
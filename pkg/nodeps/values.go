@@ -121,6 +121,23 @@ func GetValidPHPVersions() []string {
 	return s
 }
 
+// IsValidNodeJSVersion is a helper function to determine if a nodejs major version is valid
+func IsValidNodeJSVersion(nodeJSVersion string) bool {
+	_, ok := ValidNodeJSVersions[nodeJSVersion]
+	return ok
+}
+
+// GetValidNodeJSVersions is a helper function that returns a list of valid nodejs versions.
+func GetValidNodeJSVersions() []string {
+	s := make([]string, 0, len(ValidNodeJSVersions))
+
+	for v := range ValidNodeJSVersions {
+		s = append(s, v)
+	}
+	sort.Strings(s)
+	return s
+}
+
 // IsValidMariaDBVersion is a helper function to determine if a MariaDB version is valid, returning
 // true if the supplied MariaDB version is valid and false otherwise.
 func IsValidMariaDBVersion(MariaDBVersion string) bool {
@@ -11,6 +11,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -74,8 +76,58 @@ type TestSite struct {
 	FullSiteArchiveExtPath string
 }
 
+// PrepareAllWorkers is how many sites PrepareAll() downloads and copies
+// at the same time
+var PrepareAllWorkers = 4
+
+// configMutex keeps sites prepared by PrepareAll() from writing their
+// configs at the same time, since ddevapp isn't safe for concurrent use
+var configMutex sync.Mutex
+
 // Prepare downloads and extracts a site codebase to a temporary directory.
 func (site *TestSite) Prepare() error {
+	if err := site.prepareDir(); err != nil {
+		return err
+	}
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	return site.writeConfig()
+}
+
+// PrepareAll prepares sites like Prepare() does, but downloads and copies
+// up to PrepareAllWorkers of them at a time. Their Dir fields are set in
+// sites. If any fail, the error of the first of them is returned once all
+// are done.
+func PrepareAll(sites []TestSite) error {
+	errs := make([]error, len(sites))
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < PrepareAllWorkers && w < len(sites); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = sites[i].Prepare()
+			}
+		}()
+	}
+	for i := range sites {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to prepare site %s: %v", sites[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// prepareDir downloads the site's codebase, or uses the cached download,
+// and copies it to a new temporary directory in site.Dir.
+func (site *TestSite) prepareDir() error {
 	testDir := CreateTmpDir(site.Name)
 	site.Dir = testDir
 
@@ -105,7 +157,12 @@ func (site *TestSite) Prepare() error {
 		return fmt.Errorf("Failed to CopyDir from %s to %s, err=%v", cachedSrcDir, site.Dir, err)
 	}
 	output.UserOut.Println("Copying complete")
+	return nil
+}
 
+// writeConfig writes the .ddev/config.yaml of the site copied by
+// prepareDir().
+func (site *TestSite) writeConfig() error {
 	// Create an app. Err is ignored as we may not have
 	// a config file to read in from a test site.
 	app, err := ddevapp.NewApp(site.Dir, true)
@@ -264,6 +321,16 @@ func ContainerCheck(checkName string, checkState string) (bool, error) {
 	return false, errors.New("unable to find container " + checkName)
 }
 
+// DownloadRateLimit returns the bytes per second that test fixture downloads
+// are limited to, from DDEV_TEST_DOWNLOAD_RATE_LIMIT, or 0 for unlimited.
+func DownloadRateLimit() int64 {
+	limit, err := strconv.ParseInt(os.Getenv("DDEV_TEST_DOWNLOAD_RATE_LIMIT"), 10, 64)
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
+
 // GetCachedArchive returns a directory populated with the contents of the specified archive, either from cache or
 // from downloading and creating cache.
 // siteName is the site.Name used for storage
@@ -287,7 +354,7 @@ func GetCachedArchive(siteName string, prefixString string, internalExtractionPa
 
 	output.UserOut.Printf("Downloading %s", archiveFullPath)
 	_ = os.MkdirAll(extractPath, 0777)
-	err := util.DownloadFile(archiveFullPath, sourceURL, false)
+	err := util.DownloadFileWithRateLimit(archiveFullPath, sourceURL, false, DownloadRateLimit())
 	if err != nil {
 		return extractPath, archiveFullPath, fmt.Errorf("Failed to download url=%s into %s, err=%v", sourceURL, archiveFullPath, err)
 	}
@@ -14,6 +14,7 @@ import (
 	"reflect"
 	"runtime"
 	"testing"
+	"time"
 )
 
 var DdevBin = "ddev"
@@ -134,6 +135,66 @@ func TestValidTestSite(t *testing.T) {
 	assert.Error(err, "Could not stat temporary directory after cleanup")
 }
 
+// TestPrepareAll tests that PrepareAll prepares all the sites, and that
+// it's faster than preparing them one at a time
+func TestPrepareAll(t *testing.T) {
+	assert := asrt.New(t)
+
+	newSites := func(prefix string) []TestSite {
+		sites := make([]TestSite, 3)
+		for i := range sites {
+			sites[i] = TestSites[0]
+			sites[i].Name = fmt.Sprintf("%s%d", prefix, i)
+		}
+		return sites
+	}
+	serialSites := newSites("TestPrepareAllSerial")
+	parallelSites := newSites("TestPrepareAllParallel")
+
+	// Archives are cached by site name, so without a cache every site
+	// downloads its own and both timings include the downloads.
+	removeCaches := func() {
+		for _, sites := range [][]TestSite{serialSites, parallelSites} {
+			for _, site := range sites {
+				_ = os.RemoveAll(filepath.Join(globalconfig.GetGlobalDdevDir(), "testcache", site.Name))
+			}
+		}
+	}
+	removeCaches()
+	t.Cleanup(func() {
+		for _, sites := range [][]TestSite{serialSites, parallelSites} {
+			for i := range sites {
+				if sites[i].Dir != "" {
+					sites[i].Cleanup()
+				}
+			}
+		}
+		removeCaches()
+	})
+
+	start := time.Now()
+	for i := range serialSites {
+		err := serialSites[i].Prepare()
+		require.NoError(t, err)
+	}
+	serialTime := time.Since(start)
+
+	start = time.Now()
+	err := PrepareAll(parallelSites)
+	require.NoError(t, err)
+	parallelTime := time.Since(start)
+
+	for _, site := range parallelSites {
+		require.NotEmpty(t, site.Dir, "Dir of %s wasn't set", site.Name)
+		assert.DirExists(filepath.Join(site.Dir, site.Docroot))
+		assert.FileExists(filepath.Join(site.Dir, ".ddev", "config.yaml"))
+	}
+
+	// Download times vary, so this only catches sites being prepared one
+	// at a time.
+	assert.Less(parallelTime.Seconds(), serialTime.Seconds()*1.2, "PrepareAll took %s, preparing the sites one at a time took %s", parallelTime, serialTime)
+}
+
 // TestGetLocalHTTPResponse() brings up a project and hits a URL to get the response
 func TestGetLocalHTTPResponse(t *testing.T) {
 	if runtime.GOOS == "windows" || nodeps.IsMacM1() || dockerutil.IsColima() {
@@ -16,6 +16,12 @@ import (
 
 // DownloadFile retrieves a file.
 func DownloadFile(destPath string, url string, progressBar bool) (err error) {
+	return DownloadFileWithRateLimit(destPath, url, progressBar, 0)
+}
+
+// DownloadFileWithRateLimit retrieves a file like DownloadFile, but no
+// faster than bytesPerSecond. A bytesPerSecond of 0 means unlimited.
+func DownloadFileWithRateLimit(destPath string, url string, progressBar bool, bytesPerSecond int64) (err error) {
 	// Create the file
 	out, err := os.Create(destPath)
 	if err != nil {
@@ -33,14 +39,17 @@ func DownloadFile(destPath string, url string, progressBar bool) (err error) {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("download link %s returned wrong status code: got %v want %v", url, resp.StatusCode, http.StatusOK)
 	}
-	reader := resp.Body
+	var reader io.Reader = resp.Body
+	if bytesPerSecond > 0 {
+		reader = &rateLimitedReader{reader: resp.Body, bytesPerSecond: bytesPerSecond, start: time.Now()}
+	}
 	if progressBar {
 
 		bar := pb.New(int(resp.ContentLength)).SetUnits(pb.U_BYTES).Prefix(filepath.Base(destPath))
 		bar.Start()
 
 		// create proxy reader
-		reader = bar.NewProxyReader(resp.Body)
+		reader = bar.NewProxyReader(reader)
 		// Writer the body to file
 		_, err = io.Copy(out, reader)
 		bar.Finish()
@@ -55,6 +64,29 @@ func DownloadFile(destPath string, url string, progressBar bool) (err error) {
 	return nil
 }
 
+// rateLimitedReader reads no faster than bytesPerSecond on average
+type rateLimitedReader struct {
+	reader         io.Reader
+	bytesPerSecond int64
+	start          time.Time
+	total          int64
+}
+
+// Read reads at most a second's worth of bytes and then sleeps until the
+// average rate since the start is down to bytesPerSecond.
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > r.bytesPerSecond {
+		p = p[:r.bytesPerSecond]
+	}
+	n, err := r.reader.Read(p)
+	r.total += int64(n)
+	due := time.Duration(float64(r.total) / float64(r.bytesPerSecond) * float64(time.Second))
+	if wait := due - time.Since(r.start); wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
+
 // HTTPOptions defines the URL and other common HTTP options for EnsureHTTPStatus.
 type HTTPOptions struct {
 	URL            string
@@ -2,15 +2,21 @@ package util_test
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/drud/ddev/pkg/output"
 	"github.com/drud/ddev/pkg/util"
 	asrt "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestRandString ensures that RandString only generates string of the correct value and characters.
@@ -159,3 +165,32 @@ func TestConfirm(t *testing.T) {
 	assert.True(resp)
 	assert.Contains(getOutput(), text)
 }
+
+// TestDownloadFileWithRateLimit checks that a rate limited download takes
+// as long as the limit requires and gets the whole file.
+func TestDownloadFileWithRateLimit(t *testing.T) {
+	assert := asrt.New(t)
+	content := bytes.Repeat([]byte("0123456789"), 300)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "fixture")
+
+	// 3000 bytes at 1000 bytes per second takes at least 3 seconds
+	start := time.Now()
+	err := util.DownloadFileWithRateLimit(dest, server.URL, false, 1000)
+	elapsed := time.Since(start)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(elapsed, 3*time.Second)
+	downloaded, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(content, downloaded)
+
+	// Without a limit it's much quicker
+	start = time.Now()
+	err = util.DownloadFile(dest, server.URL, false)
+	require.NoError(t, err)
+	assert.Less(time.Since(start), time.Second)
+}
@@ -67,6 +67,18 @@ var SSHAuthImage = "drud/ddev-ssh-agent"
 // SSHAuthTag is ssh-agent auth tag
 var SSHAuthTag = "v1.18.0"
 
+// SolrImage is the image used for the optional solr service
+var SolrImage = "solr"
+
+// SolrTag is the tag used for the optional solr service
+var SolrTag = "8"
+
+// RedisImage is the image used for the optional redis service
+var RedisImage = "redis"
+
+// RedisTag is the tag used for the optional redis service
+var RedisTag = "6"
+
 // Busybox is used a couple of places for a quick-pull
 var BusyboxImage = "busybox:stable"
 
@@ -145,6 +157,16 @@ func GetSSHAuthImage() string {
 	return fmt.Sprintf("%s:%s", SSHAuthImage, SSHAuthTag)
 }
 
+// GetSolrImage returns the correctly formatted solr image:tag reference
+func GetSolrImage() string {
+	return fmt.Sprintf("%s:%s", SolrImage, SolrTag)
+}
+
+// GetRedisImage returns the correctly formatted redis image:tag reference
+func GetRedisImage() string {
+	return fmt.Sprintf("%s:%s", RedisImage, RedisTag)
+}
+
 // GetRouterImage returns the correctly formatted router image:tag reference
 func GetRouterImage() string {
 	return fmt.Sprintf("%s:%s", RouterImage, RouterTag)
@@ -207,18 +229,18 @@ func GetLiveDockerComposeVersion() (string, error) {
 		return DockerComposeVersion, nil
 	}
 
-	path, err := globalconfig.GetDockerComposePath()
+	path, args, err := globalconfig.GetDockerComposeCommand()
 	if err != nil {
 		return "", err
 	}
 
 	DockerComposePath := path
 
-	if !fileutil.FileExists(DockerComposePath) {
+	if globalconfig.GetCustomDockerComposeCommand() == "" && !fileutil.FileExists(DockerComposePath) {
 		DockerComposeVersion = ""
 		return DockerComposeVersion, nil
 	}
-	out, err := exec.Command(DockerComposePath, "version", "--short").Output()
+	out, err := exec.Command(DockerComposePath, append(args, "version", "--short")...).Output()
 	if err != nil {
 		return "", err
 	}
@@ -236,12 +258,13 @@ func GetLiveDockerComposeVersion() (string, error) {
 // GetRequiredDockerComposeVersion returns the version of docker-compose we need
 // based on the compiled version, or overrides in globalconfig, like
 // required_docker_compose_version and use_docker_compose_from_path
-// In the case of UseDockerComposeFromPath there is no required version, so this
+// In the case of UseDockerComposeFromPath or a custom docker_compose_command
+// there is no required version, so this
 // will return empty string.
 func GetRequiredDockerComposeVersion() string {
 	v := RequiredDockerComposeVersion
 	switch {
-	case globalconfig.DdevGlobalConfig.UseDockerComposeFromPath:
+	case globalconfig.DdevGlobalConfig.UseDockerComposeFromPath || globalconfig.GetCustomDockerComposeCommand() != "":
 		v = ""
 	case globalconfig.DdevGlobalConfig.RequiredDockerComposeVersion != "":
 		v = globalconfig.DdevGlobalConfig.RequiredDockerComposeVersion